@@ -0,0 +1,77 @@
+package generate
+
+import (
+	"os"
+
+	"github.com/harrischu/nebula-dump/cmd/root"
+	"github.com/harrischu/nebula-dump/pkg/generate"
+	"github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type generateOptsType struct {
+	file       string
+	sstPath    string
+	bufferSize int
+	tmpPath    string
+}
+
+var generateOpts generateOptsType
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "generate sst files of a tag or an edge from csv",
+	Long: `the header of tag csv is vid and the property names, the header of edge csv is src, dst, rank and the property names,
+__NULL__ is the null value. the csv exported by --output importer has no header, add the header line to use it.
+a sst file is written to every part dir in sstPath, ingest them by utils ingest or the ingest of nebula.
+the kvs over bufferSize are spilled to sorted run files in tmpPath, and merged when writing the sst files.
+the index keys are not generated, rebuild the indexes of the tag or edge after ingesting.`,
+	Example: `
+
+generate --meta 192.168.8.6:9559 --space 1 --tag 2 --file tag.player.csv --sstPath ./sst
+generate --meta 192.168.8.6:9559 --space 1 --edge 3 --file edge.follow.csv --sstPath ./sst
+generate --metaPath /data/meta/nebula/0/data --space 1 --tag 2 --file tag.player.csv --sstPath ./sst
+	`,
+	RunE: func(c *cobra.Command, args []string) error {
+		return runGenerate()
+	},
+}
+
+func init() {
+	root.RootCmd.AddCommand(generateCmd)
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.StringVar(&generateOpts.file, "file", "", "csv file of a tag or an edge")
+	flags.StringVar(&generateOpts.sstPath, "sstPath", "./sst", "output dir of the sst files")
+	flags.StringVar(&root.Opts.MetaAddres, "meta", "", "meta address. e.g. 192.168.8.6:9559")
+	flags.StringVar(&root.Opts.MetaPath, "metaPath", "", "meta rocksdb data path to read the schema without metad, e.g. /data/meta/nebula/0/data")
+	flags.BoolVar(&root.Opts.Offline, "offline", false, "use the schema cache of --meta saved by the last run, without connecting to meta")
+	flags.IntVar(&generateOpts.bufferSize, "bufferSize", generate.DefaultBufferSize>>20, "MB of the kvs kept in memory, the rest are spilled to tmpPath")
+	flags.StringVar(&generateOpts.tmpPath, "tmpPath", "", "dir of the spilled run files, the system temp dir by default")
+	cobra.MarkFlagRequired(flags, "file")
+	generateCmd.PersistentFlags().AddFlagSet(flags)
+	generateCmd.PersistentFlags().AddFlagSet(root.CommonFlagSetOption())
+}
+
+func runGenerate() error {
+	schema, err := storage.NewSchemaCache(&root.Opts)
+	if err != nil {
+		return err
+	}
+	g, err := generate.NewGenerator(&root.Opts, schema)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+	g.SetBufferSize(generateOpts.bufferSize << 20)
+	g.SetTempDir(generateOpts.tmpPath)
+	f, err := os.Open(generateOpts.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := g.Read(f); err != nil {
+		return err
+	}
+	return g.Write(generateOpts.sstPath)
+}
@@ -3,10 +3,13 @@ package cmd
 import (
 	"os"
 
+	_ "github.com/harrischu/nebula-dump/cmd/generate"
 	_ "github.com/harrischu/nebula-dump/cmd/meta"
 	"github.com/harrischu/nebula-dump/cmd/root"
+	_ "github.com/harrischu/nebula-dump/cmd/schema"
 	_ "github.com/harrischu/nebula-dump/cmd/storage"
 	_ "github.com/harrischu/nebula-dump/cmd/utils"
+	_ "github.com/harrischu/nebula-dump/cmd/wal"
 )
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -1,6 +1,8 @@
 package meta
 
 import (
+	"fmt"
+
 	"github.com/harrischu/nebula-dump/cmd/root"
 	"github.com/harrischu/nebula-dump/pkg"
 	"github.com/spf13/cobra"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/harrischu/nebula-dump/pkg/common"
 	_ "github.com/harrischu/nebula-dump/pkg/meta"
+	"github.com/harrischu/nebula-dump/pkg/output"
 )
 
 type metaOptsType struct {
@@ -47,30 +50,26 @@ func init() {
 }
 
 func runMeta(t pkg.MetaKeyType) error {
+	if root.Output == output.FormatNGQL {
+		return fmt.Errorf("ngql output only supports the storage tags and edges")
+	}
 	metaDump, err := pkg.NewMetaParser(metaOpts.path, t, &root.Opts)
 	if err != nil {
 		return err
 	}
+	w, err := root.NewWriter()
+	if err != nil {
+		return err
+	}
 	if metaOpts.raw {
-		rs, err := metaDump.Prefix()
-		if err != nil {
-			return err
-		}
-		for _, r := range rs {
-			var k, v string
-			common.ConvertBytesToString(&k, &r.Key)
-			common.ConvertBytesToString(&v, &r.Value)
-			common.Logger.Infof("key: %s, value: %s", k, v)
-
-		}
+		err = metaDump.Iterate(func(r *common.KV) error {
+			return w.Write(root.RawKVString(r))
+		})
 	} else {
-		rs, err := metaDump.ParseAll()
-		if err != nil {
-			return err
-		}
-		for _, r := range rs {
-			common.Logger.Infof("key: %s, value: %s", r.Key, r.Value)
-		}
+		err = metaDump.ParseEach(w.Write)
+	}
+	if err != nil {
+		return err
 	}
-	return nil
+	return w.Close()
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/harrischu/nebula-dump/pkg"
 	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -13,6 +14,10 @@ var (
 	keyType    string
 	rocksdbDir string
 	v          bool
+	// Output is the output format of the decoded kvs
+	Output string
+	// Batch is the rows of an INSERT statement in the ngql output
+	Batch int
 )
 
 // RootCmd represents the base command when called without any subcommands
@@ -31,14 +36,36 @@ func must(err error) {
 
 func init() {
 
+	RootCmd.PersistentFlags().StringVar(&Output, "output", output.FormatText, "output format, text, json, jsonl, csv, table, ngql or importer")
 	RootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		common.SetUpLogs(os.Stdout, v)
+		// keep the stdout for the machine readable output
+		if output.IsText(Output) {
+			common.SetUpLogs(os.Stdout, v)
+		} else {
+			common.SetUpLogs(os.Stderr, v)
+		}
 		return nil
 	}
 }
 
 var Opts = pkg.Option{}
 
+// NewWriter returns the writer of the output format.
+func NewWriter() (output.Writer, error) {
+	if Output == output.FormatNGQL {
+		return output.NewNGQLWriter(os.Stdout, Batch)
+	}
+	return output.NewWriter(Output, os.Stdout)
+}
+
+// RawKVString converts the raw kv to bytes strings.
+func RawKVString(kv *common.KV) *common.KVString {
+	var k, v string
+	common.ConvertBytesToString(&k, &kv.Key)
+	common.ConvertBytesToString(&v, &kv.Value)
+	return &common.KVString{Key: k, Value: v}
+}
+
 func CommonFlagSetOption() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.Int32Var(&Opts.SpaceID, "space", -1, "nebula space id")
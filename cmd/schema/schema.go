@@ -0,0 +1,214 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/harrischu/nebula-dump/cmd/root"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/output"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+	"github.com/spf13/cobra"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+type schemaOptsType struct {
+	meta  string
+	space int32
+	all   bool
+}
+
+var schemaOpts schemaOptsType
+
+var schemaCmd = &cobra.Command{
+	Use:               "schema",
+	Short:             "schema commands",
+	Long:              ``,
+	CompletionOptions: cobra.CompletionOptions{HiddenDefaultCmd: true},
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "manage the schema caches of the meta addresses",
+	Long: `the schema is saved to ~/.meta_cache/<meta address>/cache.yaml every time it's loaded from meta,
+the cache is used by --offline, or when the meta is unreachable.`,
+	Example: `
+
+schema cache list
+schema cache show --meta 192.168.8.6:9559 --space 1
+schema cache clear --meta 192.168.8.6:9559
+schema cache clear --all
+	`,
+	CompletionOptions: cobra.CompletionOptions{HiddenDefaultCmd: true},
+}
+
+func init() {
+	root.RootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(cacheCmd)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the cached meta addresses",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runList()
+		},
+	}
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "show the spaces, tags, edges and indexes in the cache of a meta address",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runShow()
+		},
+	}
+	showCmd.Flags().StringVar(&schemaOpts.meta, "meta", "", "meta address. e.g. 192.168.8.6:9559")
+	showCmd.Flags().Int32Var(&schemaOpts.space, "space", -1, "nebula space id")
+	cobra.MarkFlagRequired(showCmd.Flags(), "meta")
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "remove the cache of a meta address, or all caches",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runClear()
+		},
+	}
+	clearCmd.Flags().StringVar(&schemaOpts.meta, "meta", "", "meta address. e.g. 192.168.8.6:9559")
+	clearCmd.Flags().BoolVar(&schemaOpts.all, "all", false, "remove all caches")
+
+	cacheCmd.AddCommand(listCmd, showCmd, clearCmd)
+}
+
+func newWriter() (output.Writer, error) {
+	if root.Output == output.FormatNGQL || root.Output == output.FormatImporter {
+		return nil, fmt.Errorf("%s output only supports the storage tags and edges", root.Output)
+	}
+	return root.NewWriter()
+}
+
+func runList() error {
+	caches, err := schemacache.ListCaches()
+	if err != nil {
+		return err
+	}
+	w, err := newWriter()
+	if err != nil {
+		return err
+	}
+	for _, c := range caches {
+		kvstring := &common.KVString{
+			Key:   fmt.Sprintf("meta:%s", c.Address),
+			Value: fmt.Sprintf("last update time:%s, spaces:%d", c.LastUpdateTime, c.Spaces),
+		}
+		kvstring.AddField("meta", c.Address)
+		kvstring.AddField("last_update_time", c.LastUpdateTime)
+		kvstring.AddField("spaces", c.Spaces)
+		if err := w.Write(kvstring); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func runShow() error {
+	c, err := schemacache.NewOfflineCache(schemaOpts.meta)
+	if err != nil {
+		return err
+	}
+	w, err := newWriter()
+	if err != nil {
+		return err
+	}
+	common.Logger.Infof("last update time is %s", c.LastUpdateTime())
+	spaces := c.ListSpaces()
+	sort.Slice(spaces, func(i, j int) bool { return spaces[i] < spaces[j] })
+	for _, id := range spaces {
+		if schemaOpts.space != -1 && schemaOpts.space != id {
+			continue
+		}
+		if err := writeSpace(w, c, id); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// writeSpace writes the space, then its tags, edges and indexes.
+func writeSpace(w output.Writer, c schemacache.Schemacache, id int32) error {
+	newKVString := func(kind string, itemID int32, name []byte, version int64, columns []string) *common.KVString {
+		kvstring := &common.KVString{
+			Key:   fmt.Sprintf("space:%d, %s:%d", id, kind, itemID),
+			Value: fmt.Sprintf("name:%s, columns:%s", name, strings.Join(columns, ",")),
+		}
+		if kind == "tag" || kind == "edge" {
+			kvstring.Key = fmt.Sprintf("%s, version:%d", kvstring.Key, version)
+		}
+		kvstring.AddField("space", id)
+		kvstring.AddField("kind", kind)
+		kvstring.AddField("id", itemID)
+		kvstring.AddField("name", string(name))
+		kvstring.AddField("version", version)
+		kvstring.AddField("columns", strings.Join(columns, ","))
+		return kvstring
+	}
+	space := c.GetSpace(id).GetProperties()
+	kvstrings := []*common.KVString{newKVString("space", id, space.GetSpaceName(), 0, nil)}
+	kvstrings[0].Value = fmt.Sprintf(
+		"name:%s, partition_num:%d, replica_factor:%d, vid_type:%s(%d)",
+		space.GetSpaceName(),
+		space.GetPartitionNum(),
+		space.GetReplicaFactor(),
+		space.GetVidType().GetType(),
+		space.GetVidType().GetTypeLength(),
+	)
+	for _, tag := range c.GetTags(id) {
+		kvstrings = append(kvstrings, newKVString("tag", tag.GetTagID(), tag.GetTagName(), tag.GetVersion(), columnNames(tag.GetSchema())))
+	}
+	for _, edge := range c.GetEdges(id) {
+		kvstrings = append(kvstrings, newKVString("edge", edge.GetEdgeType(), edge.GetEdgeName(), edge.GetVersion(), columnNames(edge.GetSchema())))
+	}
+	for _, index := range c.GetIndexes(id) {
+		fields := make([]string, 0, len(index.GetFields()))
+		for _, f := range index.GetFields() {
+			fields = append(fields, string(f.GetName()))
+		}
+		kvstrings = append(kvstrings, newKVString("index", index.GetIndexID(), index.GetIndexName(), 0, fields))
+	}
+	for _, kvstring := range kvstrings {
+		if err := w.Write(kvstring); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnNames(s *meta.Schema) []string {
+	names := make([]string, 0, len(s.GetColumns()))
+	for _, c := range s.GetColumns() {
+		names = append(names, string(c.GetName()))
+	}
+	return names
+}
+
+func runClear() error {
+	if schemaOpts.all == (schemaOpts.meta != "") {
+		return fmt.Errorf("must provide either --meta or --all")
+	}
+	if !schemaOpts.all {
+		if err := schemacache.RemoveCache(schemaOpts.meta); err != nil {
+			return err
+		}
+		common.Logger.Infof("the schema cache of %s is removed", schemaOpts.meta)
+		return nil
+	}
+	caches, err := schemacache.ListCaches()
+	if err != nil {
+		return err
+	}
+	for _, c := range caches {
+		if err := schemacache.RemoveCache(c.Address); err != nil {
+			return err
+		}
+		common.Logger.Infof("the schema cache of %s is removed", c.Address)
+	}
+	return nil
+}
@@ -1,19 +1,25 @@
 package storage
 
 import (
+	"fmt"
+
 	"github.com/harrischu/nebula-dump/cmd/root"
 	"github.com/harrischu/nebula-dump/pkg"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"github.com/harrischu/nebula-dump/pkg/common"
-	_ "github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/harrischu/nebula-dump/pkg/importer"
+	"github.com/harrischu/nebula-dump/pkg/output"
+	"github.com/harrischu/nebula-dump/pkg/storage"
 )
 
 type storageOptsType struct {
-	raw     bool
-	path    string
-	keyType string
+	raw       bool
+	path      string
+	keyType   string
+	exportDir string
+	importer  importer.Option
 }
 
 var storageOpts storageOptsType
@@ -34,8 +40,21 @@ func init() {
 	flags.StringVar(&root.Opts.Src, "src", "", "vid")
 	flags.StringVar(&root.Opts.Dst, "dst", "", "vid")
 	flags.StringVar(&root.Opts.MetaAddres, "meta", "", "meta address. e.g. 192.168.8.6:9559")
+	flags.StringVar(&root.Opts.MetaPath, "metaPath", "", "meta rocksdb data path to read the schema without metad, e.g. /data/meta/nebula/0/data")
+	flags.BoolVar(&root.Opts.Offline, "offline", false, "use the schema cache of --meta saved by the last run, without connecting to meta")
+	flags.BoolVar(&root.Opts.WithTags, "withTags", false, "join vertices with their tag rows")
+	flags.StringVar(&root.Opts.TTL, "ttl", pkg.TTLInclude, "include, exclude or only the expired rows of tags and edges")
+	flags.Int64Var(&root.Opts.Now, "now", 0, "unix seconds to evaluate ttl, default is the current time")
+	flags.BoolVar(&root.Opts.AllParts, "all-parts", false, "dump every part of the space, instead of a part or a vid")
+	flags.BoolVar(&root.Opts.Locks, "locks", false, "list the dangling locks of the chained edges in a part")
+	flags.IntVar(&root.Batch, "batch", output.DefaultBatchSize, "rows of an INSERT statement in the ngql output, or a batch in the importer output")
+	flags.StringVar(&storageOpts.exportDir, "exportDir", "./export", "directory of the csv files and the config in the importer output")
+	flags.StringVar(&storageOpts.importer.Address, "graph", "127.0.0.1:9669", "graph address in the importer config")
+	flags.StringVar(&storageOpts.importer.User, "user", "root", "user in the importer config")
+	flags.StringVar(&storageOpts.importer.Password, "password", "nebula", "password in the importer config")
+	flags.StringVar(&root.Opts.Encoding, "encoding", pkg.EncodingText, "encoding of the raw kv, text, hex or base64")
+	flags.StringVar(&root.Opts.MissingSchema, "missingSchema", pkg.MissingSchemaFail, "when the schema version of a row is missing, fail, skip or use the nearest older version")
 
-	cobra.MarkFlagRequired(flags, "meta")
 	storageCmd.PersistentFlags().AddFlagSet(flags)
 
 	storageCmd.PersistentFlags().AddFlagSet(root.CommonFlagSetOption())
@@ -51,33 +70,81 @@ func init() {
 		}
 		storageCmd.AddCommand(c)
 	}
+	storageCmd.AddCommand(&cobra.Command{
+		Use:               pkg.StorageKeyVersions,
+		CompletionOptions: cobra.CompletionOptions{HiddenDefaultCmd: true},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersions()
+		},
+	})
 }
 
 func runStorage(t pkg.StorageKeyType) error {
+	if root.Output == output.FormatNGQL || root.Output == output.FormatImporter {
+		if (t != pkg.StorageKeyTags && t != pkg.StorageKeyEdges) || storageOpts.raw {
+			return fmt.Errorf("%s output only supports the decoded tags and edges", root.Output)
+		}
+		root.Opts.Rows = true
+	}
 	dumper, err := pkg.NewStorageParser(storageOpts.path, t, &root.Opts)
 	if err != nil {
 		return err
 	}
+	w, err := newWriter()
+	if err != nil {
+		return err
+	}
 	if storageOpts.raw {
-		rs, err := dumper.Prefix()
-		if err != nil {
-			return err
-		}
-		for _, r := range rs {
-			var k, v string
-			common.ConvertBytesToString(&k, &r.Key)
-			common.ConvertBytesToString(&v, &r.Value)
-			common.Logger.Infof("key: %s, value: %s", k, v)
-
-		}
+		err = dumper.Iterate(func(r *common.KV) error {
+			return w.Write(root.RawKVString(r))
+		})
 	} else {
-		rs, err := dumper.ParseAll()
-		if err != nil {
+		err = dumper.ParseEach(w.Write)
+	}
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// newWriter returns the writer of the output format, the importer output needs the schema.
+func newWriter() (output.Writer, error) {
+	if root.Output != output.FormatImporter {
+		return root.NewWriter()
+	}
+	schema, err := storage.NewSchemaCache(&root.Opts)
+	if err != nil {
+		return nil, err
+	}
+	opts := storageOpts.importer
+	opts.BatchSize = root.Batch
+	return importer.NewExporter(storageOpts.exportDir, root.Opts.SpaceID, schema, opts)
+}
+
+func runVersions() error {
+	if root.Output == output.FormatNGQL || root.Output == output.FormatImporter {
+		return fmt.Errorf("%s output only supports the decoded tags and edges", root.Output)
+	}
+	if storageOpts.raw {
+		return fmt.Errorf("the versions report has no raw data")
+	}
+	engine, err := common.NewRocksDbEngine(storageOpts.path)
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+	kvstrings, err := storage.VersionReport(engine, &root.Opts)
+	if err != nil {
+		return err
+	}
+	w, err := root.NewWriter()
+	if err != nil {
+		return err
+	}
+	for _, kvstring := range kvstrings {
+		if err := w.Write(kvstring); err != nil {
 			return err
 		}
-		for _, r := range rs {
-			common.Logger.Infof("key: %s, value: %s", r.Key, r.Value)
-		}
 	}
-	return nil
+	return w.Close()
 }
@@ -0,0 +1,120 @@
+package wal
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/cmd/root"
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/output"
+	"github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/harrischu/nebula-dump/pkg/wal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type walOptsType struct {
+	path string
+	from int64
+	to   int64
+}
+
+var walOpts walOptsType
+
+var walCmd = &cobra.Command{
+	Use:   "wal",
+	Short: "raft wal commands",
+	Long:  ``,
+	Example: `
+
+wal --path /data/storage/nebula/1/wal/3/
+wal --path /data/storage/nebula/1/wal/3/ --from 100 --to 200 --meta 192.168.8.6:9559 --space 1
+	`,
+	RunE: func(c *cobra.Command, args []string) error {
+		return runWal()
+	},
+}
+
+func init() {
+	root.RootCmd.AddCommand(walCmd)
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.StringVar(&walOpts.path, "path", "", "wal dir of a part, or a wal file")
+	flags.Int64Var(&walOpts.from, "from", 0, "the first log id")
+	flags.Int64Var(&walOpts.to, "to", -1, "the last log id, -1 is the last log")
+	flags.StringVar(&root.Opts.MetaAddres, "meta", "", "meta address to decode the keys, e.g. 192.168.8.6:9559")
+	flags.StringVar(&root.Opts.MetaPath, "metaPath", "", "meta rocksdb data path to decode the keys without metad, e.g. /data/meta/nebula/0/data")
+	flags.BoolVar(&root.Opts.Offline, "offline", false, "use the schema cache of --meta saved by the last run, without connecting to meta")
+	flags.StringVar(&root.Opts.MissingSchema, "missingSchema", pkg.MissingSchemaFail, "when the schema version of a row is missing, fail, skip or use the nearest older version")
+	cobra.MarkFlagRequired(flags, "path")
+	walCmd.PersistentFlags().AddFlagSet(flags)
+	walCmd.PersistentFlags().AddFlagSet(root.CommonFlagSetOption())
+}
+
+func runWal() error {
+	var (
+		decoder *storage.KeyDecoder
+		err     error
+	)
+	if root.Output == output.FormatNGQL {
+		return fmt.Errorf("ngql output only supports the storage tags and edges")
+	}
+	// keys are shown in hex without meta
+	if root.Opts.MetaAddres != "" || root.Opts.MetaPath != "" {
+		if decoder, err = storage.NewKeyDecoder(&root.Opts); err != nil {
+			return err
+		}
+	}
+	entries, err := wal.Read(walOpts.path, walOpts.from, walOpts.to, root.Opts.Limit)
+	if err != nil {
+		return err
+	}
+	w, err := root.NewWriter()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		log, err := wal.DecodeLog(e.Log)
+		if err != nil {
+			return err
+		}
+		header := fmt.Sprintf("log id:%d, term:%d, cluster:%d, type:%s, timestamp:%d",
+			e.LogID, e.Term, e.ClusterID, log.Type, log.Timestamp)
+		newKVString := func(op, key, value string) *common.KVString {
+			kvstring := &common.KVString{Key: header, Value: value}
+			if op != "" {
+				kvstring.Key = fmt.Sprintf("%s, %s %s", header, op, key)
+			}
+			kvstring.AddField("log_id", e.LogID)
+			kvstring.AddField("term", e.Term)
+			kvstring.AddField("cluster", e.ClusterID)
+			kvstring.AddField("type", log.Type.String())
+			kvstring.AddField("timestamp", log.Timestamp)
+			kvstring.AddField("op", op)
+			kvstring.AddField("key", key)
+			kvstring.AddField("value", value)
+			return kvstring
+		}
+		if len(log.Ops) == 0 {
+			if err := w.Write(newKVString("", "", hex.EncodeToString(log.Payload))); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, op := range log.Ops {
+			key, value := hex.EncodeToString(op.Key), hex.EncodeToString(op.Value)
+			if decoder != nil && op.Type != "remove range" {
+				kvstring, err := decoder.Decode(op.Key, op.Value)
+				if err != nil {
+					common.Logger.Warnf("cannot decode the key %s, log id is %d, err: %v", key, e.LogID, err)
+				} else {
+					key, value = kvstring.Key, kvstring.Value
+				}
+			}
+			if err := w.Write(newKVString(op.Type, key, value)); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Close()
+}
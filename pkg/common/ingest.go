@@ -28,3 +28,22 @@ func Ingest(sstPath, dbPath string) error {
 	return e.db.IngestExternalFile(files, grocksdb.NewDefaultIngestExternalFileOptions())
 
 }
+
+// WriteSst writes the kvs passed to fn by iterate to a sst file, the keys must be sorted and unique.
+func WriteSst(path string, iterate func(fn func(*KV) error) error) error {
+	envOpts := grocksdb.NewDefaultEnvOptions()
+	defer envOpts.Destroy()
+	opts := grocksdb.NewDefaultOptions()
+	defer opts.Destroy()
+	w := grocksdb.NewSSTFileWriter(envOpts, opts)
+	defer w.Destroy()
+	if err := w.Open(path); err != nil {
+		return err
+	}
+	if err := iterate(func(kv *KV) error {
+		return w.Add(kv.Key, kv.Value)
+	}); err != nil {
+		return err
+	}
+	return w.Finish()
+}
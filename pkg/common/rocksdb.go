@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/facebook/fbthrift/thrift/lib/go/thrift"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
 
 	gorocksdb "github.com/linxGnu/grocksdb"
 )
@@ -18,6 +19,30 @@ type (
 	KVString struct {
 		Key   string
 		Value string
+		// decoded fields in order, for the structured output
+		Fields []Field
+		// the decoded vertex or edge for the nGQL and csv export, only the tag and edge rows have it
+		Row *Row
+	}
+
+	Field struct {
+		Name  string
+		Value interface{}
+	}
+
+	// Row is a decoded vertex or edge with the properties of a tag or an edge.
+	// vids are int64 or the string without the padding of the fixed length.
+	Row struct {
+		Edge    bool
+		Name    string
+		Columns []string
+		// property types of the columns, FIXED_STRING values keep the padding
+		Types []nebula.PropertyType
+		// vid of the vertex, or src of the edge
+		Vid    *nebula.Value
+		Dst    *nebula.Value
+		Rank   int64
+		Values []*nebula.Value
 	}
 
 	Engine struct {
@@ -41,6 +66,11 @@ func NewKV(key []byte, value []byte) *KV {
 	return kv
 }
 
+// AddField appends a decoded field.
+func (kv *KVString) AddField(name string, value interface{}) {
+	kv.Fields = append(kv.Fields, Field{Name: name, Value: value})
+}
+
 func NewRocksDbEngine(path string) (*Engine, error) {
 	e := &Engine{}
 	e.dbOps = gorocksdb.NewDefaultOptions()
@@ -74,22 +104,44 @@ func (e *Engine) Open() error {
 	return nil
 }
 
+// Close closes the db if it's opened, the engine could be opened again.
+func (e *Engine) Close() {
+	if e.db != nil {
+		e.db.Close()
+		e.db = nil
+	}
+}
+
 func (e *Engine) Prefix(p []byte, limit int) ([]*KV, error) {
 	return e.PrefixWithCondition(p, limit, nil, nil)
 }
 
 func (e *Engine) PrefixWithCondition(p []byte, limit int, keyCondition, valueCondition conditionFunc) ([]*KV, error) {
+	kvs := make([]*KV, 0)
+	err := e.PrefixEach(p, limit, keyCondition, valueCondition, func(kv *KV) error {
+		kvs = append(kvs, kv)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kvs, nil
+}
+
+// PrefixEach calls fn with every kv which has the prefix p and matches the conditions, at most limit kvs.
+// the kvs are read one by one, it stops and returns the error when fn returns an error.
+func (e *Engine) PrefixEach(p []byte, limit int, keyCondition, valueCondition conditionFunc, fn func(*KV) error) error {
 	Logger.Debugf("prefix with condition, prefix is %v", p)
 	if limit < 1 {
-		return []*KV{}, nil
+		return nil
 	}
 	if e.db == nil {
 		err := e.Open()
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
-	kvs := make([]*KV, 0)
+	count := 0
 	iter := e.db.NewIterator(e.readOps)
 	defer iter.Close()
 	iter.Seek(p)
@@ -101,7 +153,7 @@ func (e *Engine) PrefixWithCondition(p []byte, limit int, keyCondition, valueCon
 		if bytes.Compare(p, iter.Key().Data()[:l]) != 0 {
 			break
 		}
-		if len(kvs) == limit {
+		if count == limit {
 			break
 		}
 		if keyCondition != nil && !keyCondition(iter.Key().Data()) {
@@ -110,11 +162,49 @@ func (e *Engine) PrefixWithCondition(p []byte, limit int, keyCondition, valueCon
 		if valueCondition != nil && !valueCondition(iter.Value().Data()) {
 			continue
 		}
-		kv := NewKV(iter.Key().Data(), iter.Value().Data())
+		count++
+		if err := fn(NewKV(iter.Key().Data(), iter.Value().Data())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Collect collects all kvs of the iterate function.
+func Collect(iterate func(fn func(*KV) error) error) ([]*KV, error) {
+	kvs := make([]*KV, 0)
+	err := iterate(func(kv *KV) error {
 		kvs = append(kvs, kv)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return kvs, nil
+}
 
+// Scan calls fn with every key value which has the prefix p, stops when fn returns false.
+// the key and value are only valid in fn.
+func (e *Engine) Scan(p []byte, fn func(key, value []byte) bool) error {
+	Logger.Debugf("scan, prefix is %v", p)
+	if e.db == nil {
+		err := e.Open()
+		if err != nil {
+			return err
+		}
+	}
+	iter := e.db.NewIterator(e.readOps)
+	defer iter.Close()
+	iter.Seek(p)
+	for ; iter.Valid(); iter.Next() {
+		if !bytes.HasPrefix(iter.Key().Data(), p) {
+			break
+		}
+		if !fn(iter.Key().Data(), iter.Value().Data()) {
+			break
+		}
+	}
+	return nil
 }
 
 func deserialize(pf thrift.ProtocolFactory, data *[]byte, s thrift.Struct) error {
@@ -103,14 +103,18 @@ func GetPartPrefix(partID int, keyType int) ([]byte, error) {
 	return data, nil
 }
 
+// GetPartID follows nebula, the int64 vid is hashed as uint64, so that a negative vid is in a valid part.
 func GetPartID(vid []byte, count int32) (int32, error) {
 	var partID int32
+	if count <= 0 {
+		return 0, fmt.Errorf("invalid parts count %d", count)
+	}
 	if len(vid) == 8 {
-		var v int64
+		var v uint64
 		if err := ConvertBytesToInt(&v, &vid, ByteOrder); err != nil {
 			return 0, err
 		}
-		partID = int32(v%int64(count)) + 1
+		partID = int32(v%uint64(count)) + 1
 
 	} else {
 		uv := NebulaMmhash(vid)
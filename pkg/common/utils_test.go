@@ -2,7 +2,10 @@ package common
 
 import (
 	"encoding/binary"
+	"math"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestIntToBytes(t *testing.T) {
@@ -29,3 +32,26 @@ func TestB(t *testing.T) {
 	t.Fatal(1)
 
 }
+
+func TestGetPartID(t *testing.T) {
+	for _, c := range []struct {
+		vid   int64
+		count int32
+		part  int32
+	}{
+		{30786325636933, 96, 30786325636933%96 + 1},
+		{-1, 10, 6},
+		{-7, 3, 1},
+		{math.MinInt64, 7, int32(uint64(1<<63)%7) + 1},
+	} {
+		vid := make([]byte, 8)
+		binary.LittleEndian.PutUint64(vid, uint64(c.vid))
+		part, err := GetPartID(vid, c.count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, c.part, part, "vid:%d", c.vid)
+	}
+	_, err := GetPartID([]byte("a"), 0)
+	assert.Error(t, err)
+}
@@ -11,11 +11,17 @@ type (
 		Parse(*common.KV) (*common.KVString, error)
 		New(engine *common.Engine, opt *Option) Parser
 		Prefix() ([]*common.KV, error)
+		// Iterate calls fn with the kvs of Prefix one by one, stops when fn returns an error.
+		Iterate(fn func(*common.KV) error) error
 	}
 
 	Dumper interface {
 		ParseAll() ([]*common.KVString, error)
 		Prefix() ([]*common.KV, error)
+		// Iterate calls fn with the raw kvs one by one.
+		Iterate(fn func(*common.KV) error) error
+		// ParseEach calls fn with the parsed kvs one by one, the kvs skipped by the parser are not passed to fn.
+		ParseEach(fn func(*common.KVString) error) error
 	}
 
 	Option struct {
@@ -29,6 +35,26 @@ type (
 		VID        string
 		Src        string
 		Dst        string
+		// rocksdb data dir of metad, the schema is read from it instead of MetaAddres
+		MetaPath string
+		// load the schema cache of MetaAddres saved by the last run, without connecting to metad
+		Offline bool
+		// policy when the schema version of a row is missing
+		MissingSchema string
+		// ttl mode, include, exclude or only the expired rows
+		TTL string
+		// unix seconds to evaluate ttl, 0 is the current time
+		Now int64
+		// join vertices with their tag rows
+		WithTags bool
+		// encoding of the raw kv, text, hex or base64
+		Encoding string
+		// list the dangling locks of the chained edges
+		Locks bool
+		// dump every part of the space
+		AllParts bool
+		// keep the decoded vertices and edges for the nGQL and csv export
+		Rows bool
 	}
 
 	MetaDumper struct {
@@ -69,26 +95,21 @@ func NewMetaParser(path string, keyType MetaKeyType, option *Option) (Dumper, er
 }
 
 func (m *MetaDumper) ParseAll() ([]*common.KVString, error) {
-	r := make([]*common.KVString, 0)
-	kvs, err := m.Prefix()
-	if err != nil {
-		return nil, err
-	}
-	for _, kv := range kvs {
-		kvstring, err := m.parser.Parse(kv)
-		if err != nil {
-			return nil, fmt.Errorf("key is %v, value is %v, err: %v", kv.Key, kv.Value, err)
-		}
-		r = append(r, kvstring)
+	return collectKVStrings(m.ParseEach)
+}
 
-	}
-	return r, nil
+func (m *MetaDumper) ParseEach(fn func(*common.KVString) error) error {
+	return parseEach(m.parser, fn)
 }
 
 func (m *MetaDumper) Prefix() ([]*common.KV, error) {
 	return m.parser.Prefix()
 }
 
+func (m *MetaDumper) Iterate(fn func(*common.KV) error) error {
+	return m.parser.Iterate(fn)
+}
+
 func NewStorageParser(path string, keyType StorageKeyType, option *Option) (Dumper, error) {
 	s := &StorageDumper{}
 	p, ok := StorageKeyTypeMap[keyType]
@@ -109,23 +130,44 @@ func NewStorageParser(path string, keyType StorageKeyType, option *Option) (Dump
 }
 
 func (m *StorageDumper) ParseAll() ([]*common.KVString, error) {
-	r := make([]*common.KVString, 0)
-	kvs, err := m.Prefix()
-	if err != nil {
-		return nil, err
-	}
-	for _, kv := range kvs {
-		kvstring, err := m.parser.Parse(kv)
-		if err != nil {
-			return nil, fmt.Errorf("key is %v, value is %v, err: %v", kv.Key, kv.Value, err)
-		}
-		r = append(r, kvstring)
+	return collectKVStrings(m.ParseEach)
+}
 
-	}
-	return r, nil
+func (m *StorageDumper) ParseEach(fn func(*common.KVString) error) error {
+	return parseEach(m.parser, fn)
 }
 
 func (m *StorageDumper) Prefix() ([]*common.KV, error) {
 	return m.parser.Prefix()
 
 }
+
+func (m *StorageDumper) Iterate(fn func(*common.KV) error) error {
+	return m.parser.Iterate(fn)
+}
+
+func parseEach(p Parser, fn func(*common.KVString) error) error {
+	return p.Iterate(func(kv *common.KV) error {
+		kvstring, err := p.Parse(kv)
+		if err != nil {
+			return fmt.Errorf("key is %v, value is %v, err: %v", kv.Key, kv.Value, err)
+		}
+		// the parser skips the key
+		if kvstring == nil {
+			return nil
+		}
+		return fn(kvstring)
+	})
+}
+
+func collectKVStrings(parseEach func(fn func(*common.KVString) error) error) ([]*common.KVString, error) {
+	r := make([]*common.KVString, 0)
+	err := parseEach(func(kvstring *common.KVString) error {
+		r = append(r, kvstring)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,338 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+	"github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+// DefaultBufferSize is the bytes of the kvs kept in memory before spilling them to the disk.
+const DefaultBufferSize = 256 << 20
+
+// the memory of a kv besides the key and the value
+const kvOverhead = 64
+
+// Generator encodes the rows of a tag or an edge in csv to the kvs of nebula,
+// and writes a sorted sst file per part. it's the reverse of the storage parsers.
+// tag csv: vid + properties, edge csv: src + dst + rank + properties, with a header of the names.
+// the kvs are buffered in memory, a full buffer is spilled to a sorted run file per part,
+// and the runs are merged on Write, so that the input could be larger than the memory.
+type Generator struct {
+	opts    *pkg.Option
+	schema  schemacache.Schemacache
+	edge    bool
+	id      int32
+	version int64
+	columns []*meta.ColumnDef
+	// timestamp of the rows in microseconds
+	timestamp int64
+	parts     map[int32][]*common.KV
+	// bytes of the kvs in parts
+	buffered   int
+	bufferSize int
+	// parent dir of the run files, the system temp dir if empty
+	tempDir string
+	// dir of the run files, created on the first spill
+	runDir string
+	runs   map[int32][]string
+}
+
+func NewGenerator(opts *pkg.Option, schema schemacache.Schemacache) (*Generator, error) {
+	if schema.GetSpace(opts.SpaceID) == nil {
+		return nil, fmt.Errorf("cannot find the space")
+	}
+	g := &Generator{
+		opts:       opts,
+		schema:     schema,
+		timestamp:  time.Now().UnixNano() / 1000,
+		parts:      make(map[int32][]*common.KV),
+		bufferSize: DefaultBufferSize,
+		runs:       make(map[int32][]string),
+	}
+	var (
+		s     *meta.Schema
+		found bool
+	)
+	switch {
+	case opts.TagID != -1 && opts.EdgeID == 0:
+		g.id = opts.TagID
+		for _, item := range schema.GetTags(opts.SpaceID) {
+			if item.TagID == opts.TagID && (!found || item.Version > g.version) {
+				s, g.version, found = item.Schema, item.Version, true
+			}
+		}
+	case opts.TagID == -1 && opts.EdgeID > 0:
+		g.edge, g.id = true, opts.EdgeID
+		for _, item := range schema.GetEdges(opts.SpaceID) {
+			if item.EdgeType == opts.EdgeID && (!found || item.Version > g.version) {
+				s, g.version, found = item.Schema, item.Version, true
+			}
+		}
+	default:
+		return nil, fmt.Errorf("must provide a tag or a positive edge type")
+	}
+	if !found {
+		return nil, fmt.Errorf("cannot find the schema")
+	}
+	g.columns = s.GetColumns()
+	g.warnIndexes()
+	return g, nil
+}
+
+// warnIndexes warns the indexes of the tag or edge, the index keys are not generated.
+func (g *Generator) warnIndexes() {
+	names := make([]string, 0)
+	for _, index := range g.schema.GetIndexes(g.opts.SpaceID) {
+		id := index.GetSchemaID()
+		if (g.edge && id.IsSetEdgeType() && id.GetEdgeType() == g.id) || (!g.edge && id.IsSetTagID() && id.GetTagID() == g.id) {
+			names = append(names, string(index.GetIndexName()))
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	t := "TAG"
+	if g.edge {
+		t = "EDGE"
+	}
+	common.Logger.Warnf("the index keys are not generated, run REBUILD %s INDEX %s after ingesting the sst files",
+		t, strings.Join(names, ", "))
+}
+
+// SetBufferSize sets the bytes of the kvs kept in memory.
+func (g *Generator) SetBufferSize(size int) {
+	g.bufferSize = size
+}
+
+// SetTempDir sets the parent dir of the run files spilled from the buffer.
+func (g *Generator) SetTempDir(dir string) {
+	g.tempDir = dir
+}
+
+// Read encodes the rows in the csv with the latest schema.
+func (g *Generator) Read(r io.Reader) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("cannot read the header, err: %w", err)
+	}
+	keys := []string{"vid"}
+	if g.edge {
+		keys = []string{"src", "dst", "rank"}
+	}
+	if len(header) < len(keys) {
+		return fmt.Errorf("invalid header %v", header)
+	}
+	for i, k := range keys {
+		if header[i] != k {
+			return fmt.Errorf("invalid header %v, column %d should be %s", header, i, k)
+		}
+	}
+	// index of the column in the csv, -1 if it's missing
+	index := make([]int, len(g.columns))
+	// value of the missing column, follow nebula, use the default value first, then null
+	missing := make([]*nebula.Value, len(g.columns))
+	for i, c := range g.columns {
+		index[i] = -1
+		for j := len(keys); j < len(header); j++ {
+			if header[j] == string(c.GetName()) {
+				index[i] = j
+			}
+		}
+		if index[i] != -1 {
+			continue
+		}
+		d, err := storage.ColumnDefault(c)
+		if err != nil {
+			return fmt.Errorf("column %s is missing, cannot decode its default value, err: %w", c.GetName(), err)
+		}
+		switch {
+		case d != nil:
+			missing[i] = d
+		case c.GetNullable():
+			missing[i] = nullValue()
+		default:
+			return fmt.Errorf("column %s is missing", c.GetName())
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := g.add(record, index, missing); err != nil {
+			return fmt.Errorf("line:%d, err: %w", line, err)
+		}
+	}
+}
+
+func (g *Generator) add(record []string, index []int, missing []*nebula.Value) error {
+	row := &nebula.Row{Values: make([]*nebula.Value, len(g.columns))}
+	for i, c := range g.columns {
+		if index[i] == -1 {
+			row.Values[i] = missing[i]
+			continue
+		}
+		v, err := parseValue(record[index[i]], c.GetType())
+		if err != nil {
+			return fmt.Errorf("column:%s, err: %w", c.GetName(), err)
+		}
+		row.Values[i] = v
+	}
+	value, err := storage.NewRowWriter(&meta.Schema{Columns: g.columns}, g.version).Write(row, g.timestamp)
+	if err != nil {
+		return err
+	}
+
+	vid, part, err := storage.EncodeVid(record[0], g.opts.SpaceID, g.schema)
+	if err != nil {
+		return err
+	}
+	if !g.edge {
+		g.put(part, storage.TagKey(part, vid, g.id), value)
+		return g.spillIfFull()
+	}
+	dst, dstPart, err := storage.EncodeVid(record[1], g.opts.SpaceID, g.schema)
+	if err != nil {
+		return err
+	}
+	rank, err := strconv.ParseInt(record[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rank %s", record[2])
+	}
+	// the out edge in the part of src, and the in edge in the part of dst
+	g.put(part, storage.EdgeKey(part, vid, g.id, rank, dst), value)
+	g.put(dstPart, storage.EdgeKey(dstPart, dst, -g.id, rank, vid), value)
+	return g.spillIfFull()
+}
+
+func (g *Generator) put(part int32, key, value []byte) {
+	g.parts[part] = append(g.parts[part], common.NewKV(key, value))
+	g.buffered += len(key) + len(value) + kvOverhead
+}
+
+func (g *Generator) spillIfFull() error {
+	if g.buffered < g.bufferSize {
+		return nil
+	}
+	return g.spill()
+}
+
+// spill writes the sorted kvs of every part in the buffer to a run file, and clears the buffer.
+func (g *Generator) spill() error {
+	if g.runDir == "" {
+		dir, err := ioutil.TempDir(g.tempDir, "nebula-dump-runs")
+		if err != nil {
+			return err
+		}
+		g.runDir = dir
+	}
+	for part, kvs := range g.parts {
+		path := filepath.Join(g.runDir, fmt.Sprintf("%d-%d.run", part, len(g.runs[part])))
+		if err := writeRun(path, sortKVs(kvs)); err != nil {
+			return err
+		}
+		g.runs[part] = append(g.runs[part], path)
+	}
+	common.Logger.Debugf("%d bytes of kvs are spilled to %s", g.buffered, g.runDir)
+	g.parts = make(map[int32][]*common.KV)
+	g.buffered = 0
+	return nil
+}
+
+// Write writes the kvs of every part to dir/<part>/<tag|edge>-<id>.sst,
+// the later row wins when the keys are the same. the run files are removed.
+func (g *Generator) Write(dir string) error {
+	defer g.Close()
+	name := fmt.Sprintf("tag-%d.sst", g.id)
+	if g.edge {
+		name = fmt.Sprintf("edge-%d.sst", g.id)
+	}
+	parts := make([]int32, 0, len(g.parts))
+	for part := range g.parts {
+		parts = append(parts, part)
+	}
+	for part := range g.runs {
+		if _, ok := g.parts[part]; !ok {
+			parts = append(parts, part)
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	for _, part := range parts {
+		path := filepath.Join(dir, strconv.Itoa(int(part)))
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+		count := 0
+		err := common.WriteSst(filepath.Join(path, name), func(fn func(*common.KV) error) error {
+			return g.iteratePart(part, func(kv *common.KV) error {
+				count++
+				return fn(kv)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("part:%d, err: %w", part, err)
+		}
+		common.Logger.Infof("part:%d, %d kvs are written", part, count)
+	}
+	return nil
+}
+
+// iteratePart merges the runs and the buffer of the part, calls fn with the sorted and unique kvs.
+func (g *Generator) iteratePart(part int32, fn func(*common.KV) error) error {
+	readers := make([]kvReader, 0, len(g.runs[part])+1)
+	for _, path := range g.runs[part] {
+		r, err := openRun(path)
+		if err != nil {
+			return err
+		}
+		defer r.close()
+		readers = append(readers, r)
+	}
+	// the buffer has the latest rows
+	readers = append(readers, &sliceReader{kvs: sortKVs(g.parts[part])})
+	return merge(readers, fn)
+}
+
+// Close removes the run files.
+func (g *Generator) Close() error {
+	if g.runDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(g.runDir)
+	g.runDir, g.runs = "", make(map[int32][]string)
+	return err
+}
+
+// sortKVs sorts the kvs by key, and removes the duplicated keys except the last one.
+func sortKVs(kvs []*common.KV) []*common.KV {
+	sort.SliceStable(kvs, func(i, j int) bool { return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0 })
+	r := make([]*common.KV, 0, len(kvs))
+	for _, kv := range kvs {
+		if n := len(r); n > 0 && bytes.Equal(r[n-1].Key, kv.Key) {
+			r[n-1] = kv
+			continue
+		}
+		r = append(r, kv)
+	}
+	return r
+}
@@ -0,0 +1,216 @@
+package generate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+type fakeSchema struct {
+	space   *meta.SpaceItem
+	tags    []*meta.TagItem
+	edges   []*meta.EdgeItem
+	indexes []*meta.IndexItem
+}
+
+func (f *fakeSchema) Update() error                            { return nil }
+func (f *fakeSchema) ListSpaces() []int32                      { return []int32{1} }
+func (f *fakeSchema) GetSpace(space int32) *meta.SpaceItem     { return f.space }
+func (f *fakeSchema) GetTags(space int32) []*meta.TagItem      { return f.tags }
+func (f *fakeSchema) GetEdges(space int32) []*meta.EdgeItem    { return f.edges }
+func (f *fakeSchema) GetIndexes(space int32) []*meta.IndexItem { return f.indexes }
+func (f *fakeSchema) Close() error                             { return nil }
+
+func newColumn(name string, t nebula.PropertyType, nullable bool) *meta.ColumnDef {
+	c := meta.NewColumnDef()
+	c.Name = []byte(name)
+	c.Type = meta.NewColumnTypeDef()
+	c.Type.Type = t
+	c.Nullable = nullable
+	return c
+}
+
+func newFakeSchema() *fakeSchema {
+	space := meta.NewSpaceItem()
+	space.Properties = meta.NewSpaceDesc()
+	space.Properties.PartitionNum = 10
+	space.Properties.VidType.Type = nebula.PropertyType_INT64
+	space.Properties.VidType.TypeLength = 8
+	return &fakeSchema{
+		space: space,
+		tags: []*meta.TagItem{
+			{TagID: 2, Version: 0, Schema: &meta.Schema{Columns: []*meta.ColumnDef{newColumn("name", nebula.PropertyType_STRING, false)}}},
+			{TagID: 2, Version: 1, Schema: &meta.Schema{Columns: []*meta.ColumnDef{
+				newColumn("name", nebula.PropertyType_STRING, false), newColumn("age", nebula.PropertyType_INT8, true)}}},
+		},
+		edges: []*meta.EdgeItem{
+			{EdgeType: 3, Version: 0, Schema: &meta.Schema{Columns: []*meta.ColumnDef{newColumn("start", nebula.PropertyType_DATE, false)}}},
+		},
+	}
+}
+
+func TestGenerateTag(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	g, err := NewGenerator(&pkg.Option{SpaceID: 1, TagID: 2}, newFakeSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.timestamp = 1
+	// the later row of the same vid wins
+	assert.NoError(t, g.Read(strings.NewReader("vid,name,age\n13,Tim,__NULL__\n3,Tony,40\n13,Tim,42\n")))
+	assert.Equal(t, []int32{4}, keys(g.parts))
+	kvs := sortKVs(g.parts[4])
+	assert.Equal(t, 2, len(kvs))
+	assert.Equal(t, []byte{0x01, 4, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0}, kvs[0].Key)
+	assert.Equal(t, []byte{0x01, 4, 0, 0, 13, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0}, kvs[1].Key)
+	// header, version, null bitmap, name, age, string heap and timestamp
+	assert.Equal(t, []byte{0x09, 1, 0, 12, 0, 0, 0, 3, 0, 0, 0, 42, 'T', 'i', 'm', 1, 0, 0, 0, 0, 0, 0, 0}, kvs[1].Value)
+
+	assert.Error(t, g.Read(strings.NewReader("vid,age\n1,1\n")))
+	assert.Error(t, g.Read(strings.NewReader("vid,name,age\n1,Tim,128\n")))
+	assert.Error(t, g.Read(strings.NewReader("id,name\n1,Tim\n")))
+
+	_, err = NewGenerator(&pkg.Option{SpaceID: 1, TagID: 5}, newFakeSchema())
+	assert.Error(t, err)
+}
+
+func TestGenerateDefault(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	schema := newFakeSchema()
+	// int constant expressions, age is 7 and level is 9
+	age, level := newColumn("age", nebula.PropertyType_INT8, true), newColumn("level", nebula.PropertyType_INT64, false)
+	age.DefaultValue = []byte{0, 4, 0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0}
+	level.DefaultValue = []byte{0, 4, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0, 0}
+	schema.tags = append(schema.tags, &meta.TagItem{TagID: 2, Version: 2, Schema: &meta.Schema{Columns: []*meta.ColumnDef{
+		newColumn("name", nebula.PropertyType_STRING, false), age, level}}})
+	g, err := NewGenerator(&pkg.Option{SpaceID: 1, TagID: 2}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.timestamp = 1
+	assert.NoError(t, g.Read(strings.NewReader("vid,name\n3,Tony\n")))
+	kvs := g.parts[4]
+	assert.Equal(t, 1, len(kvs))
+	// header, version, null bitmap, name, age, level, string heap and timestamp
+	assert.Equal(t, []byte{0x09, 2, 0, 20, 0, 0, 0, 4, 0, 0, 0, 7, 9, 0, 0, 0, 0, 0, 0, 0, 'T', 'o', 'n', 'y', 1, 0, 0, 0, 0, 0, 0, 0}, kvs[0].Value)
+
+	// the default value cannot be evaluated offline
+	level.DefaultValue = []byte{1}
+	assert.Error(t, g.Read(strings.NewReader("vid,name\n3,Tony\n")))
+}
+
+func TestGenerateNegativeVid(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	g, err := NewGenerator(&pkg.Option{SpaceID: 1, TagID: 2}, newFakeSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// uint64(-1) % 10 + 1, uint64(-7) % 10 + 1
+	assert.NoError(t, g.Read(strings.NewReader("vid,name\n-1,Tim\n-7,Tony\n")))
+	dir := t.TempDir()
+	assert.NoError(t, g.Write(dir))
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make([]string, 0)
+	for _, f := range files {
+		parts = append(parts, f.Name())
+	}
+	assert.Equal(t, []string{"10", "6"}, parts)
+}
+
+func TestGenerateEdge(t *testing.T) {
+	g, err := NewGenerator(&pkg.Option{SpaceID: 1, TagID: -1, EdgeID: 3}, newFakeSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, g.Read(strings.NewReader("src,dst,rank,start\n1,2,-1,2022-01-02\n")))
+	assert.Equal(t, []int32{2, 3}, keys(g.parts))
+	rank := []byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	out := append([]byte{0x02, 2, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0}, rank...)
+	out = append(out, 2, 0, 0, 0, 0, 0, 0, 0, 1)
+	in := append([]byte{0x02, 3, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0xfd, 0xff, 0xff, 0xff}, rank...)
+	in = append(in, 1, 0, 0, 0, 0, 0, 0, 0, 1)
+	assert.Equal(t, out, g.parts[2][0].Key)
+	assert.Equal(t, in, g.parts[3][0].Key)
+	assert.Equal(t, g.parts[2][0].Value, g.parts[3][0].Value)
+
+	_, err = NewGenerator(&pkg.Option{SpaceID: 1, TagID: -1, EdgeID: -3}, newFakeSchema())
+	assert.Error(t, err)
+}
+
+func keys(parts map[int32][]*common.KV) []int32 {
+	r := make([]int32, 0)
+	for p := int32(1); p <= 10; p++ {
+		if _, ok := parts[p]; ok {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func TestGenerateSpill(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	csv := "vid,name,age\n13,Tim,__NULL__\n3,Tony,40\n23,Tom,1\n13,Tim,42\n5,Jim,2\n3,Tony,41\n"
+	collect := func(bufferSize int) map[int32][]*common.KV {
+		g, err := NewGenerator(&pkg.Option{SpaceID: 1, TagID: 2}, newFakeSchema())
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.timestamp = 1
+		g.SetBufferSize(bufferSize)
+		g.SetTempDir(t.TempDir())
+		assert.NoError(t, g.Read(strings.NewReader(csv)))
+		if bufferSize == 1 {
+			// every row is spilled
+			assert.Empty(t, g.parts)
+			assert.Equal(t, 5, len(g.runs[4]))
+		}
+		r := make(map[int32][]*common.KV)
+		for _, part := range []int32{4, 6} {
+			assert.NoError(t, g.iteratePart(part, func(kv *common.KV) error {
+				r[part] = append(r[part], kv)
+				return nil
+			}))
+		}
+		runDir := g.runDir
+		assert.NoError(t, g.Close())
+		if runDir != "" {
+			_, err := os.Stat(runDir)
+			assert.True(t, os.IsNotExist(err))
+		}
+		return r
+	}
+	expected := collect(DefaultBufferSize)
+	// 3, 13 and 23 are in part 4, 5 is in part 6
+	assert.Equal(t, 3, len(expected[4]))
+	assert.Equal(t, 1, len(expected[6]))
+	assert.Equal(t, expected, collect(1))
+}
+
+func TestGenerateIndexWarning(t *testing.T) {
+	b := &bytes.Buffer{}
+	common.SetUpLogs(b, false)
+	defer common.SetUpLogs(ioutil.Discard, false)
+	s := newFakeSchema()
+	tagID, edgeType := int32(2), int32(3)
+	s.indexes = []*meta.IndexItem{
+		{IndexName: []byte("player_index"), SchemaID: &nebula.SchemaID{TagID: &tagID}},
+		{IndexName: []byte("follow_index"), SchemaID: &nebula.SchemaID{EdgeType: &edgeType}},
+	}
+	_, err := NewGenerator(&pkg.Option{SpaceID: 1, TagID: 2}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, b.String(), "REBUILD TAG INDEX player_index")
+	assert.NotContains(t, b.String(), "follow_index")
+}
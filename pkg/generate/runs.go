@@ -0,0 +1,166 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// kvReader reads the sorted kvs one by one, next returns nil at the end.
+type kvReader interface {
+	next() (*common.KV, error)
+}
+
+type sliceReader struct {
+	kvs []*common.KV
+}
+
+func (r *sliceReader) next() (*common.KV, error) {
+	if len(r.kvs) == 0 {
+		return nil, nil
+	}
+	kv := r.kvs[0]
+	r.kvs = r.kvs[1:]
+	return kv, nil
+}
+
+// writeRun writes the sorted kvs to a run file.
+// kv: key length(uvarint) + key + value length(uvarint) + value
+func writeRun(path string, kvs []*common.KV) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	l := make([]byte, binary.MaxVarintLen64)
+	for _, kv := range kvs {
+		for _, b := range [][]byte{kv.Key, kv.Value} {
+			n := binary.PutUvarint(l, uint64(len(b)))
+			if _, err := w.Write(l[:n]); err != nil {
+				return err
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+type runReader struct {
+	f *os.File
+	r *bufio.Reader
+}
+
+func openRun(path string) (*runReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &runReader{f: f, r: bufio.NewReader(f)}, nil
+}
+
+func (r *runReader) next() (*common.KV, error) {
+	key, err := r.read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.read()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &common.KV{Key: key, Value: value}, nil
+}
+
+func (r *runReader) read() ([]byte, error) {
+	l, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *runReader) close() error {
+	return r.f.Close()
+}
+
+type heapItem struct {
+	kv  *common.KV
+	run int
+}
+
+// kvHeap pops the smallest key, and the later run first for the same key.
+type kvHeap []*heapItem
+
+func (h kvHeap) Len() int { return len(h) }
+func (h kvHeap) Less(i, j int) bool {
+	if c := bytes.Compare(h[i].kv.Key, h[j].kv.Key); c != 0 {
+		return c < 0
+	}
+	return h[i].run > h[j].run
+}
+func (h kvHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *kvHeap) Push(x interface{}) { *h = append(*h, x.(*heapItem)) }
+func (h *kvHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// merge calls fn with the kvs of the sorted runs in order,
+// the kv in the later run wins when the keys are the same.
+func merge(runs []kvReader, fn func(*common.KV) error) error {
+	h := &kvHeap{}
+	push := func(run int) error {
+		kv, err := runs[run].next()
+		if err != nil {
+			return err
+		}
+		if kv != nil {
+			heap.Push(h, &heapItem{kv: kv, run: run})
+		}
+		return nil
+	}
+	for i := range runs {
+		if err := push(i); err != nil {
+			return err
+		}
+	}
+	var last []byte
+	for h.Len() > 0 {
+		item := heap.Pop(h).(*heapItem)
+		if last == nil || !bytes.Equal(last, item.kv.Key) {
+			if err := fn(item.kv); err != nil {
+				return err
+			}
+			last = item.kv.Key
+		}
+		if err := push(item.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package generate
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+// layouts of the text in csv, same as the storage parsers
+const (
+	dateLayout     = "2006-01-02"
+	timeLayout     = "15:04:05.999999"
+	dateTimeLayout = "2006-01-02T15:04:05.999999"
+)
+
+func nullValue() *nebula.Value {
+	t := nebula.NullType___NULL__
+	return nebula.NewValue().SetNVal(&t)
+}
+
+// parseValue parses the text of the type, storage.NullText is the null.
+func parseValue(s string, t *meta.ColumnTypeDef) (*nebula.Value, error) {
+	if s == storage.NullText {
+		return nullValue(), nil
+	}
+	v := nebula.NewValue()
+	switch t.GetType() {
+	case nebula.PropertyType_BOOL:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		v.SetBVal(&b)
+
+	case nebula.PropertyType_INT8, nebula.PropertyType_INT16, nebula.PropertyType_INT32,
+		nebula.PropertyType_INT64, nebula.PropertyType_TIMESTAMP:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		v.SetIVal(&i)
+
+	case nebula.PropertyType_FLOAT, nebula.PropertyType_DOUBLE:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		v.SetFVal(&f)
+
+	case nebula.PropertyType_STRING, nebula.PropertyType_FIXED_STRING:
+		v.SetSVal([]byte(s))
+
+	case nebula.PropertyType_DATE:
+		d, err := time.Parse(dateLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		v.SetDVal(&nebula.Date{Year: int16(d.Year()), Month: int8(d.Month()), Day: int8(d.Day())})
+
+	case nebula.PropertyType_TIME:
+		d, err := time.Parse(timeLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		v.SetTVal(&nebula.Time{Hour: int8(d.Hour()), Minute: int8(d.Minute()), Sec: int8(d.Second()), Microsec: int32(d.Nanosecond() / 1000)})
+
+	case nebula.PropertyType_DATETIME:
+		d, err := time.Parse(dateTimeLayout, s)
+		if err != nil {
+			return nil, err
+		}
+		v.SetDtVal(&nebula.DateTime{
+			Year:     int16(d.Year()),
+			Month:    int8(d.Month()),
+			Day:      int8(d.Day()),
+			Hour:     int8(d.Hour()),
+			Minute:   int8(d.Minute()),
+			Sec:      int8(d.Second()),
+			Microsec: int32(d.Nanosecond() / 1000),
+		})
+
+	default:
+		return nil, fmt.Errorf("not support to generate this tpye: %s", t.GetType())
+	}
+	return v, nil
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+// config of nebula-importer, version v2
+type (
+	config struct {
+		Version        string         `yaml:"version"`
+		Description    string         `yaml:"description"`
+		ClientSettings clientSettings `yaml:"clientSettings"`
+		LogPath        string         `yaml:"logPath"`
+		Files          []*fileConfig  `yaml:"files"`
+	}
+
+	clientSettings struct {
+		Retry             int        `yaml:"retry"`
+		Concurrency       int        `yaml:"concurrency"`
+		ChannelBufferSize int        `yaml:"channelBufferSize"`
+		Space             string     `yaml:"space"`
+		Connection        connection `yaml:"connection"`
+		PostStart         *postStart `yaml:"postStart,omitempty"`
+	}
+
+	connection struct {
+		User     string `yaml:"user"`
+		Password string `yaml:"password"`
+		Address  string `yaml:"address"`
+	}
+
+	postStart struct {
+		Commands    string `yaml:"commands"`
+		AfterPeriod string `yaml:"afterPeriod"`
+	}
+
+	fileConfig struct {
+		Path         string       `yaml:"path"`
+		FailDataPath string       `yaml:"failDataPath"`
+		BatchSize    int          `yaml:"batchSize"`
+		Type         string       `yaml:"type"`
+		CSV          csvConfig    `yaml:"csv"`
+		Schema       schemaConfig `yaml:"schema"`
+	}
+
+	csvConfig struct {
+		WithHeader bool   `yaml:"withHeader"`
+		WithLabel  bool   `yaml:"withLabel"`
+		Delimiter  string `yaml:"delimiter"`
+	}
+
+	schemaConfig struct {
+		Type   string        `yaml:"type"`
+		Vertex *vertexConfig `yaml:"vertex,omitempty"`
+		Edge   *edgeConfig   `yaml:"edge,omitempty"`
+	}
+
+	vertexConfig struct {
+		VID  vidConfig    `yaml:"vid"`
+		Tags []*tagConfig `yaml:"tags"`
+	}
+
+	tagConfig struct {
+		Name  string        `yaml:"name"`
+		Props []*propConfig `yaml:"props"`
+	}
+
+	edgeConfig struct {
+		Name        string        `yaml:"name"`
+		WithRanking bool          `yaml:"withRanking"`
+		SrcVID      vidConfig     `yaml:"srcVID"`
+		DstVID      vidConfig     `yaml:"dstVID"`
+		Rank        *rankConfig   `yaml:"rank,omitempty"`
+		Props       []*propConfig `yaml:"props"`
+	}
+
+	vidConfig struct {
+		Index int    `yaml:"index"`
+		Type  string `yaml:"type"`
+	}
+
+	rankConfig struct {
+		Index int `yaml:"index"`
+	}
+
+	propConfig struct {
+		Name      string `yaml:"name"`
+		Type      string `yaml:"type"`
+		Index     int    `yaml:"index"`
+		Nullable  bool   `yaml:"nullable,omitempty"`
+		NullValue string `yaml:"nullValue,omitempty"`
+	}
+)
+
+// createSchema returns the statements to create the space, and the exported tags and edges
+// by the latest schema. default values are not created, the rows always have all the values.
+func (e *Exporter) createSchema() (string, error) {
+	space := e.schema.GetSpace(e.spaceID).GetProperties()
+	vidType := "INT64"
+	if t := space.GetVidType(); t.GetType() != nebula.PropertyType_INT64 {
+		vidType = fmt.Sprintf("FIXED_STRING(%d)", t.GetTypeLength())
+	}
+	name := identifier(string(space.GetSpaceName()))
+	statements := []string{
+		fmt.Sprintf("CREATE SPACE IF NOT EXISTS %s(partition_num = %d, replica_factor = %d, vid_type = %s)",
+			name, space.GetPartitionNum(), space.GetReplicaFactor(), vidType),
+		fmt.Sprintf("USE %s", name),
+	}
+	created := make(map[string]bool)
+	for _, f := range e.files {
+		t := "TAG"
+		if f.edge {
+			t = "EDGE"
+		}
+		if created[t+f.name] {
+			continue
+		}
+		created[t+f.name] = true
+		s, err := e.latestSchema(f.edge, f.name)
+		if err != nil {
+			return "", err
+		}
+		columns := make([]string, 0, len(s.GetColumns()))
+		for _, c := range s.GetColumns() {
+			ct, err := columnType(c.GetType())
+			if err != nil {
+				return "", fmt.Errorf("%s, column:%s, err: %w", f.name, c.GetName(), err)
+			}
+			null := "NOT NULL"
+			if c.GetNullable() {
+				null = "NULL"
+			}
+			columns = append(columns, fmt.Sprintf("%s %s %s", identifier(string(c.GetName())), ct, null))
+		}
+		statement := fmt.Sprintf("CREATE %s IF NOT EXISTS %s(%s)", t, identifier(f.name), strings.Join(columns, ", "))
+		if prop := s.GetSchemaProp(); len(prop.GetTtlCol()) != 0 && prop.GetTtlDuration() > 0 {
+			statement += fmt.Sprintf(" TTL_DURATION = %d, TTL_COL = %q", prop.GetTtlDuration(), prop.GetTtlCol())
+		}
+		statements = append(statements, statement)
+	}
+	return strings.Join(statements, "; ") + ";", nil
+}
+
+// columnType returns the type in nGQL.
+func columnType(t *meta.ColumnTypeDef) (string, error) {
+	switch t.GetType() {
+	case nebula.PropertyType_FIXED_STRING:
+		return fmt.Sprintf("FIXED_STRING(%d)", t.GetTypeLength()), nil
+	case nebula.PropertyType_GEOGRAPHY:
+		if t.GetGeoShape() == meta.GeoShape_ANY {
+			return "GEOGRAPHY", nil
+		}
+		return fmt.Sprintf("GEOGRAPHY(%s)", t.GetGeoShape()), nil
+	case nebula.PropertyType_BOOL, nebula.PropertyType_INT8, nebula.PropertyType_INT16,
+		nebula.PropertyType_INT32, nebula.PropertyType_INT64, nebula.PropertyType_FLOAT,
+		nebula.PropertyType_DOUBLE, nebula.PropertyType_STRING, nebula.PropertyType_TIMESTAMP,
+		nebula.PropertyType_DATE, nebula.PropertyType_TIME, nebula.PropertyType_DATETIME,
+		nebula.PropertyType_DURATION:
+		return t.GetType().String(), nil
+	default:
+		return "", fmt.Errorf("cannot create the type %s", t.GetType())
+	}
+}
+
+func identifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
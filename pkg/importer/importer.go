@@ -0,0 +1,325 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+	"github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigName is the nebula-importer config in the export directory.
+const ConfigName = "importer.yaml"
+
+type (
+	// Option is the connection and the batch size in the nebula-importer config.
+	Option struct {
+		Address   string
+		User      string
+		Password  string
+		BatchSize int
+	}
+
+	// Exporter writes the tag and edge rows to a csv file per tag or edge,
+	// and the nebula-importer config to load the files into a fresh space on Close.
+	// the files have no header, the columns are vid, or src, dst and rank, then the properties in the config.
+	Exporter struct {
+		dir     string
+		spaceID int32
+		schema  schemacache.Schemacache
+		opts    Option
+		files   []*csvFile
+		// tag or edge name with the columns to the file
+		index map[string]*csvFile
+	}
+
+	csvFile struct {
+		edge    bool
+		name    string
+		columns []string
+		path    string
+		f       *os.File
+		w       *csv.Writer
+	}
+)
+
+func NewExporter(dir string, spaceID int32, schema schemacache.Schemacache, opts Option) (*Exporter, error) {
+	if schema.GetSpace(spaceID) == nil {
+		return nil, fmt.Errorf("cannot find the space")
+	}
+	if opts.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid batch size %d", opts.BatchSize)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Exporter{
+		dir:     dir,
+		spaceID: spaceID,
+		schema:  schema,
+		opts:    opts,
+		index:   make(map[string]*csvFile),
+	}, nil
+}
+
+func (e *Exporter) Write(kv *common.KVString) error {
+	r := kv.Row
+	if r == nil {
+		common.Logger.Debugf("skip %s, it's not a vertex or an out edge", kv.Key)
+		return nil
+	}
+	f, err := e.getFile(r)
+	if err != nil {
+		return err
+	}
+	record := []string{csvValue(r.Vid)}
+	if r.Edge {
+		record = append(record, csvValue(r.Dst), strconv.FormatInt(r.Rank, 10))
+	}
+	for _, v := range storage.RowValues(r) {
+		record = append(record, csvValue(v))
+	}
+	return f.w.Write(record)
+}
+
+// csvValue formats the value as nebula-importer reads it, strings are not quoted,
+// and the null is storage.NullText.
+func csvValue(value *nebula.Value) string {
+	switch {
+	case value.IsSetNVal():
+		return storage.NullText
+	case value.IsSetSVal():
+		return string(value.GetSVal())
+	default:
+		return storage.FormatValue(value)
+	}
+}
+
+// getFile returns the file of the tag or edge, rows decoded by different schema versions
+// may have different columns, they are written to another file.
+func (e *Exporter) getFile(r *common.Row) (*csvFile, error) {
+	key := fmt.Sprintf("%t/%s/%s", r.Edge, r.Name, strings.Join(r.Columns, ","))
+	if f, ok := e.index[key]; ok {
+		return f, nil
+	}
+	t := "tag"
+	if r.Edge {
+		t = "edge"
+	}
+	name := fmt.Sprintf("%s.%s.csv", t, r.Name)
+	for n := 1; e.exists(name); n++ {
+		name = fmt.Sprintf("%s.%s.%d.csv", t, r.Name, n)
+	}
+	file, err := os.Create(filepath.Join(e.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	f := &csvFile{
+		edge:    r.Edge,
+		name:    r.Name,
+		columns: r.Columns,
+		path:    name,
+		f:       file,
+		w:       csv.NewWriter(file),
+	}
+	e.files = append(e.files, f)
+	e.index[key] = f
+	return f, nil
+}
+
+func (e *Exporter) exists(path string) bool {
+	for _, f := range e.files {
+		if f.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Close flushes the csv files and writes the config.
+func (e *Exporter) Close() error {
+	for _, f := range e.files {
+		f.w.Flush()
+		if err := f.w.Error(); err != nil {
+			return err
+		}
+		if err := f.f.Close(); err != nil {
+			return err
+		}
+	}
+	c, err := e.config()
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(e.dir, ConfigName), b, 0644)
+}
+
+func (e *Exporter) config() (*config, error) {
+	space := e.schema.GetSpace(e.spaceID).GetProperties()
+	vidType := "string"
+	if space.GetVidType().GetType() == nebula.PropertyType_INT64 {
+		vidType = "int"
+	}
+	commands, err := e.createSchema()
+	if err != nil {
+		return nil, err
+	}
+	c := &config{
+		Version:     "v2",
+		Description: fmt.Sprintf("exported by nebula-dump from space %s", space.GetSpaceName()),
+		ClientSettings: clientSettings{
+			Retry:             3,
+			Concurrency:       10,
+			ChannelBufferSize: 128,
+			Space:             string(space.GetSpaceName()),
+			Connection: connection{
+				User:     e.opts.User,
+				Password: e.opts.Password,
+				Address:  e.opts.Address,
+			},
+			// wait for the schema to be synchronized to the graphd and storaged
+			PostStart: &postStart{Commands: commands, AfterPeriod: "20s"},
+		},
+		LogPath: "./err/importer.log",
+		Files:   make([]*fileConfig, 0, len(e.files)),
+	}
+	for _, f := range e.files {
+		s, err := e.latestSchema(f.edge, f.name)
+		if err != nil {
+			return nil, err
+		}
+		offset := 1
+		if f.edge {
+			offset = 3
+		}
+		props, err := propConfigs(f.columns, s, offset)
+		if err != nil {
+			return nil, fmt.Errorf("%s, err: %w", f.path, err)
+		}
+		fc := &fileConfig{
+			Path:         "./" + f.path,
+			FailDataPath: "./err/" + f.path,
+			BatchSize:    e.opts.BatchSize,
+			Type:         "csv",
+			// nebula-importer takes the props from a header in its own syntax and ignores the props here,
+			// without the header, the columns are mapped by the index, and the null value works.
+			CSV: csvConfig{WithHeader: false, Delimiter: ","},
+		}
+		if f.edge {
+			fc.Schema = schemaConfig{Type: "edge", Edge: &edgeConfig{
+				Name:        f.name,
+				WithRanking: true,
+				SrcVID:      vidConfig{Index: 0, Type: vidType},
+				DstVID:      vidConfig{Index: 1, Type: vidType},
+				Rank:        &rankConfig{Index: 2},
+				Props:       props,
+			}}
+		} else {
+			fc.Schema = schemaConfig{Type: "vertex", Vertex: &vertexConfig{
+				VID:  vidConfig{Index: 0, Type: vidType},
+				Tags: []*tagConfig{{Name: f.name, Props: props}},
+			}}
+		}
+		c.Files = append(c.Files, fc)
+	}
+	return c, nil
+}
+
+// propConfigs maps the columns to the latest schema, the dropped columns are ignored.
+func propConfigs(columns []string, s *meta.Schema, offset int) ([]*propConfig, error) {
+	props := make([]*propConfig, 0, len(columns))
+	for i, c := range columns {
+		col := getColumn(s, c)
+		if col == nil {
+			common.Logger.Warnf("column %s is dropped, ignore it", c)
+			continue
+		}
+		t, err := propType(col.GetType())
+		if err != nil {
+			return nil, fmt.Errorf("column:%s, err: %w", c, err)
+		}
+		p := &propConfig{Name: c, Type: t, Index: offset + i}
+		if col.GetNullable() {
+			p.Nullable, p.NullValue = true, storage.NullText
+		}
+		props = append(props, p)
+	}
+	return props, nil
+}
+
+func getColumn(s *meta.Schema, name string) *meta.ColumnDef {
+	for _, c := range s.GetColumns() {
+		if string(c.GetName()) == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// latestSchema returns the schema of the newest version.
+func (e *Exporter) latestSchema(edge bool, name string) (*meta.Schema, error) {
+	var (
+		s *meta.Schema
+		v int64 = -1
+	)
+	if edge {
+		for _, item := range e.schema.GetEdges(e.spaceID) {
+			if string(item.EdgeName) == name && item.Version > v {
+				s, v = item.Schema, item.Version
+			}
+		}
+	} else {
+		for _, item := range e.schema.GetTags(e.spaceID) {
+			if string(item.TagName) == name && item.Version > v {
+				s, v = item.Schema, item.Version
+			}
+		}
+	}
+	if s == nil {
+		return nil, fmt.Errorf("cannot find the schema of %s", name)
+	}
+	return s, nil
+}
+
+// propType returns the type in nebula-importer.
+func propType(t *meta.ColumnTypeDef) (string, error) {
+	switch t.GetType() {
+	case nebula.PropertyType_BOOL:
+		return "bool", nil
+	case nebula.PropertyType_INT8, nebula.PropertyType_INT16, nebula.PropertyType_INT32, nebula.PropertyType_INT64:
+		return "int", nil
+	case nebula.PropertyType_FLOAT:
+		return "float", nil
+	case nebula.PropertyType_DOUBLE:
+		return "double", nil
+	case nebula.PropertyType_STRING, nebula.PropertyType_FIXED_STRING:
+		return "string", nil
+	case nebula.PropertyType_TIMESTAMP:
+		return "timestamp", nil
+	case nebula.PropertyType_DATE:
+		return "date", nil
+	case nebula.PropertyType_TIME:
+		return "time", nil
+	case nebula.PropertyType_DATETIME:
+		return "datetime", nil
+	case nebula.PropertyType_GEOGRAPHY:
+		if t.GetGeoShape() == meta.GeoShape_ANY {
+			return "geography", nil
+		}
+		return fmt.Sprintf("geography(%s)", strings.ToLower(t.GetGeoShape().String())), nil
+	default:
+		return "", fmt.Errorf("nebula-importer does not support the type %s", t.GetType())
+	}
+}
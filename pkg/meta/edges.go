@@ -70,10 +70,15 @@ func (p *edgeParser) Parse(kv *common.KV) (*common.KVString, error) {
 		name,
 		strings.Join(columns, ","),
 	)
+	kvstring.AddField("space", spaceID)
+	kvstring.AddField("edge", EdgeID)
+	kvstring.AddField("version", versionNum)
+	kvstring.AddField("name", string(name))
+	kvstring.AddField("columns", strings.Join(columns, ","))
 	return kvstring, nil
 }
 
-func (p *edgeParser) Prefix() ([]*common.KV, error) {
+func (p *edgeParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 	var (
 		spaceID []byte
@@ -81,16 +86,20 @@ func (p *edgeParser) Prefix() ([]*common.KV, error) {
 	)
 	if p.opts.SpaceID != -1 {
 		if err := common.ConvertIntToBytes(&p.opts.SpaceID, &spaceID, common.ByteOrder); err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, spaceID...)
 
 		if p.opts.EdgeID != 0 {
 			if err := common.ConvertIntToBytes(&p.opts.EdgeID, &EdgeID, common.ByteOrder); err != nil {
-				return nil, err
+				return err
 			}
 			s = append(s, EdgeID...)
 		}
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *edgeParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
@@ -54,22 +54,26 @@ func (p *hostParser) Parse(kv *common.KV) (*common.KVString, error) {
 		hostStr,
 		portNum,
 	)
-	v, err := p.getValue(kv.Value)
-	if err != nil {
+	kvstring.AddField("host", hostStr)
+	kvstring.AddField("port", portNum)
+	if err := p.getValue(kvstring, kv.Value); err != nil {
 		return nil, err
 	}
-	kvstring.Value = v
 
 	return kvstring, nil
 }
 
-func (p *hostParser) Prefix() ([]*common.KV, error) {
+func (p *hostParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *hostParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
 
-func (p *hostParser) getValue(v []byte) (string, error) {
+func (p *hostParser) getValue(kvstring *common.KVString, v []byte) error {
 	var (
 		dataVersion int8
 		lastHBInMs  int64
@@ -80,24 +84,28 @@ func (p *hostParser) getValue(v []byte) (string, error) {
 	dataV := v[:1]
 
 	if err := common.ConvertBytesToInt(&dataVersion, &dataV, common.ByteOrder); err != nil {
-		return "", err
+		return err
 	}
 	if dataVersion != 2 {
-		return "", fmt.Errorf("data format is invalid")
+		return fmt.Errorf("data format is invalid")
 	}
 	t, r, l := v[1:1+8], v[1+8:1+8+4], v[1+8+4:1+8+4+8]
 
 	if err := common.ConvertBytesToInt(&lastHBInMs, &t, common.ByteOrder); err != nil {
-		return "", err
+		return err
 	}
 	if err := common.ConvertBytesToInt(&roleNum, &r, common.ByteOrder); err != nil {
-		return "", err
+		return err
 	}
 	if err := common.ConvertBytesToInt(&shaLength, &l, common.ByteOrder); err != nil {
-		return "", err
+		return err
 	}
 
 	sha = string(v[1+8+4+8 : 1+8+4+8+shaLength])
 	tm := time.Unix(lastHBInMs/1e3, (lastHBInMs%1e3)*1e6)
-	return fmt.Sprintf("time:%s, role:%d, sha: %s", tm.Format("2006-01-02T15:04:05.000Z"), roleNum, sha), nil
+	kvstring.Value = fmt.Sprintf("time:%s, role:%d, sha: %s", tm.Format("2006-01-02T15:04:05.000Z"), roleNum, sha)
+	kvstring.AddField("time", tm.Format("2006-01-02T15:04:05.000Z"))
+	kvstring.AddField("role", roleNum)
+	kvstring.AddField("sha", sha)
+	return nil
 }
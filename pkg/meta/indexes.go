@@ -61,10 +61,14 @@ func (p *indexParser) Parse(kv *common.KV) (*common.KVString, error) {
 		indexItem.IndexName,
 		strings.Join(names, ","),
 	)
+	kvstring.AddField("space", spaceID)
+	kvstring.AddField("index", indexID)
+	kvstring.AddField("name", string(indexItem.IndexName))
+	kvstring.AddField("fields", strings.Join(names, ","))
 	return kvstring, nil
 }
 
-func (p *indexParser) Prefix() ([]*common.KV, error) {
+func (p *indexParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 	var (
 		spaceID []byte
@@ -72,18 +76,22 @@ func (p *indexParser) Prefix() ([]*common.KV, error) {
 	)
 	if p.opts.SpaceID != -1 {
 		if err := common.ConvertIntToBytes(&p.opts.SpaceID, &spaceID, common.ByteOrder); err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, spaceID...)
 
 		if p.opts.IndexID != -1 {
 			if err := common.ConvertIntToBytes(&p.opts.IndexID, &IndexID, common.ByteOrder); err != nil {
-				return nil, err
+				return err
 			}
 			s = append(s, IndexID...)
 		}
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *indexParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
 
 func parseIndex(v []byte) (*meta.IndexItem, error) {
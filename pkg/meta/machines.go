@@ -53,11 +53,17 @@ func (p *machineParser) Parse(kv *common.KV) (*common.KVString, error) {
 		hostStr,
 		portNum,
 	)
+	kvstring.AddField("host", hostStr)
+	kvstring.AddField("port", portNum)
 	return kvstring, nil
 }
 
-func (p *machineParser) Prefix() ([]*common.KV, error) {
+func (p *machineParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *machineParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
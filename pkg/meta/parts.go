@@ -46,10 +46,14 @@ func (p *partParser) Parse(kv *common.KV) (*common.KVString, error) {
 	}
 	kvstring.Key = fmt.Sprintf("space:%d, part:%d", spaceId, partId)
 	kvstring.Value = fmt.Sprintf("version:%d, hosts are %s", version, string(kv.Value[4:]))
+	kvstring.AddField("space", spaceId)
+	kvstring.AddField("part", partId)
+	kvstring.AddField("version", version)
+	kvstring.AddField("hosts", string(kv.Value[4:]))
 	return kvstring, nil
 }
 
-func (p *partParser) Prefix() ([]*common.KV, error) {
+func (p *partParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 	var (
 		spaceID []byte
@@ -57,16 +61,20 @@ func (p *partParser) Prefix() ([]*common.KV, error) {
 	)
 	if p.opts.SpaceID != -1 {
 		if err := common.ConvertIntToBytes(&p.opts.SpaceID, &spaceID, common.ByteOrder); err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, spaceID...)
 
 		if p.opts.PartID != -1 {
 			if err := common.ConvertIntToBytes(&p.opts.TagID, &partID, common.ByteOrder); err != nil {
-				return nil, err
+				return err
 			}
 			s = append(s, partID...)
 		}
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *partParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
@@ -0,0 +1,227 @@
+package meta
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+const (
+	spacesKey  = "__spaces__"
+	tagsKey    = "__tags__"
+	edgesKey   = "__edges__"
+	indexesKey = "__indexes__"
+)
+
+// RocksdbCache is the schema read from the rocksdb data dir of metad,
+// it needs no network, so that the storage data could be decoded when the cluster is down.
+type RocksdbCache struct {
+	engine  *common.Engine
+	spaces  map[int32]*meta.SpaceItem
+	tags    map[int32][]*meta.TagItem
+	edges   map[int32][]*meta.EdgeItem
+	indexes map[int32][]*meta.IndexItem
+	rwMutex sync.RWMutex
+}
+
+var _ schemacache.Schemacache = &RocksdbCache{}
+
+// NewRocksdbCache opens the meta rocksdb in read only mode, e.g. /data/meta/nebula/0/data.
+func NewRocksdbCache(path string) (*RocksdbCache, error) {
+	engine, err := common.NewRocksDbEngine(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RocksdbCache{
+		engine:  engine,
+		spaces:  make(map[int32]*meta.SpaceItem),
+		tags:    make(map[int32][]*meta.TagItem),
+		edges:   make(map[int32][]*meta.EdgeItem),
+		indexes: make(map[int32][]*meta.IndexItem),
+	}, nil
+}
+
+func (c *RocksdbCache) Update() error {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+	c.spaces = make(map[int32]*meta.SpaceItem)
+	c.tags = make(map[int32][]*meta.TagItem)
+	c.edges = make(map[int32][]*meta.EdgeItem)
+	c.indexes = make(map[int32][]*meta.IndexItem)
+	for _, p := range []struct {
+		key string
+		fn  func(*common.KV) error
+	}{
+		{spacesKey, c.addSpace},
+		{tagsKey, c.addTag},
+		{edgesKey, c.addEdge},
+		{indexesKey, c.addIndex},
+	} {
+		if err := c.engine.PrefixEach([]byte(p.key), math.MaxInt32, nil, nil, p.fn); err != nil {
+			return fmt.Errorf("%s, err: %w", p.key, err)
+		}
+	}
+	return nil
+}
+
+// key: __spaces__ + space id
+func (c *RocksdbCache) addSpace(kv *common.KV) error {
+	var spaceID int32
+	b := kv.Key[len(spacesKey):]
+	if len(b) != common.Sizeof(spaceID) {
+		return fmt.Errorf("invalid space key, length is %d", len(kv.Key))
+	}
+	if err := common.ConvertBytesToInt(&spaceID, &b, common.ByteOrder); err != nil {
+		return err
+	}
+	desc, err := parseSpaceDesc(kv.Value)
+	if err != nil {
+		return fmt.Errorf("space:%d, err: %w", spaceID, err)
+	}
+	c.spaces[spaceID] = &meta.SpaceItem{SpaceID: spaceID, Properties: desc}
+	return nil
+}
+
+func (c *RocksdbCache) addTag(kv *common.KV) error {
+	spaceID, tagID, version, err := parseSchemaKey(kv.Key, tagsKey)
+	if err != nil {
+		return err
+	}
+	name, schema, err := parseSchemaValue(kv.Value)
+	if err != nil {
+		return fmt.Errorf("space:%d, tag:%d, err: %w", spaceID, tagID, err)
+	}
+	c.tags[spaceID] = append(c.tags[spaceID], &meta.TagItem{
+		TagID:   tagID,
+		TagName: name,
+		Version: version,
+		Schema:  schema,
+	})
+	return nil
+}
+
+func (c *RocksdbCache) addEdge(kv *common.KV) error {
+	spaceID, edgeType, version, err := parseSchemaKey(kv.Key, edgesKey)
+	if err != nil {
+		return err
+	}
+	name, schema, err := parseSchemaValue(kv.Value)
+	if err != nil {
+		return fmt.Errorf("space:%d, edge:%d, err: %w", spaceID, edgeType, err)
+	}
+	c.edges[spaceID] = append(c.edges[spaceID], &meta.EdgeItem{
+		EdgeType: edgeType,
+		EdgeName: name,
+		Version:  version,
+		Schema:   schema,
+	})
+	return nil
+}
+
+// key: __indexes__ + space id + index id
+func (c *RocksdbCache) addIndex(kv *common.KV) error {
+	var spaceID, indexID int32
+	l := len(indexesKey)
+	if len(kv.Key) != l+common.Sizeof(spaceID)+common.Sizeof(indexID) {
+		return fmt.Errorf("invalid index key, length is %d", len(kv.Key))
+	}
+	space := kv.Key[l : l+4]
+	if err := common.ConvertBytesToInt(&spaceID, &space, common.ByteOrder); err != nil {
+		return err
+	}
+	item, err := parseIndex(kv.Value)
+	if err != nil {
+		return fmt.Errorf("space:%d, err: %w", spaceID, err)
+	}
+	c.indexes[spaceID] = append(c.indexes[spaceID], item)
+	return nil
+}
+
+// parseSchemaKey decodes the key of tags and edges.
+// key: prefix + space id + tag id or edge type + (MaxInt64 - version)
+func parseSchemaKey(key []byte, prefix string) (int32, int32, int64, error) {
+	var (
+		spaceID int32
+		id      int32
+		version int64
+	)
+	l := len(prefix)
+	if len(key) != l+common.Sizeof(spaceID)+common.Sizeof(id)+common.Sizeof(version) {
+		return 0, 0, 0, fmt.Errorf("invalid schema key, length is %d", len(key))
+	}
+	space, i, v := key[l:l+4], key[l+4:l+8], key[l+8:]
+	if err := common.ConvertBytesToInt(&spaceID, &space, common.ByteOrder); err != nil {
+		return 0, 0, 0, err
+	}
+	if err := common.ConvertBytesToInt(&id, &i, common.ByteOrder); err != nil {
+		return 0, 0, 0, err
+	}
+	if err := common.ConvertBytesToInt(&version, &v, common.ByteOrder); err != nil {
+		return 0, 0, 0, err
+	}
+	return spaceID, id, math.MaxInt64 - version, nil
+}
+
+// parseSchemaValue decodes the value of tags and edges.
+// value: length of name (4 bit) + name + CompactSerializer of schema
+func parseSchemaValue(value []byte) ([]byte, *meta.Schema, error) {
+	var length int32
+	if len(value) < common.Sizeof(length) {
+		return nil, nil, fmt.Errorf("invalid schema value, length is %d", len(value))
+	}
+	b := value[:4]
+	if err := common.ConvertBytesToInt(&length, &b, common.ByteOrder); err != nil {
+		return nil, nil, err
+	}
+	if length < 0 || len(value) < 4+int(length) {
+		return nil, nil, fmt.Errorf("invalid schema value, name length is %d", length)
+	}
+	schema, err := parseSchema(value[4+int(length):])
+	if err != nil {
+		return nil, nil, err
+	}
+	return value[4 : 4+int(length)], schema, nil
+}
+
+func (c *RocksdbCache) ListSpaces() []int32 {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+	d := make([]int32, 0, len(c.spaces))
+	for id := range c.spaces {
+		d = append(d, id)
+	}
+	return d
+}
+
+func (c *RocksdbCache) GetSpace(space int32) *meta.SpaceItem {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+	return c.spaces[space]
+}
+
+func (c *RocksdbCache) GetTags(space int32) []*meta.TagItem {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+	return c.tags[space]
+}
+
+func (c *RocksdbCache) GetEdges(space int32) []*meta.EdgeItem {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+	return c.edges[space]
+}
+
+func (c *RocksdbCache) GetIndexes(space int32) []*meta.IndexItem {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+	return c.indexes[space]
+}
+
+func (c *RocksdbCache) Close() error {
+	c.engine.Close()
+	return nil
+}
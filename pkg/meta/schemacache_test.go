@@ -0,0 +1,89 @@
+package meta
+
+import (
+	"math"
+	"testing"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+func schemaKV(t *testing.T, prefix string, space, id int32, version int64, name string, s *meta.Schema) *common.KV {
+	l := len(prefix)
+	key := make([]byte, l+16)
+	copy(key, prefix)
+	common.ByteOrder.PutUint32(key[l:], uint32(space))
+	common.ByteOrder.PutUint32(key[l+4:], uint32(id))
+	common.ByteOrder.PutUint64(key[l+8:], uint64(math.MaxInt64-version))
+	var b []byte
+	if err := common.CompactSerializer(s, &b); err != nil {
+		t.Fatal(err)
+	}
+	value := make([]byte, 4)
+	common.ByteOrder.PutUint32(value, uint32(len(name)))
+	value = append(value, name...)
+	return common.NewKV(key, append(value, b...))
+}
+
+func TestRocksdbCache(t *testing.T) {
+	c, err := NewRocksdbCache("/tmp/meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := meta.NewSpaceDesc()
+	desc.SpaceName = []byte("basketball")
+	desc.PartitionNum = 10
+	desc.VidType = &meta.ColumnTypeDef{Type: nebula.PropertyType_FIXED_STRING, TypeLength: 32}
+	var b []byte
+	if err := common.CompactSerializer(desc, &b); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, c.addSpace(common.NewKV(append([]byte(spacesKey), 1, 0, 0, 0), b)))
+
+	s := meta.NewSchema()
+	s.Columns = []*meta.ColumnDef{{Name: []byte("age"), Type: &meta.ColumnTypeDef{Type: nebula.PropertyType_INT64}}}
+	assert.NoError(t, c.addTag(schemaKV(t, tagsKey, 1, 2, 0, "player", s)))
+	assert.NoError(t, c.addTag(schemaKV(t, tagsKey, 1, 2, 1, "player", s)))
+	assert.NoError(t, c.addEdge(schemaKV(t, edgesKey, 1, 3, 0, "follow", s)))
+
+	index := meta.NewIndexItem()
+	index.IndexID = 4
+	index.IndexName = []byte("player_index")
+	b = nil
+	if err := common.CompactSerializer(index, &b); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, c.addIndex(common.NewKV(append([]byte(indexesKey), 1, 0, 0, 0, 4, 0, 0, 0), b)))
+
+	assert.Equal(t, []int32{1}, c.ListSpaces())
+	space := c.GetSpace(1)
+	assert.Equal(t, int32(1), space.GetSpaceID())
+	assert.Equal(t, []byte("basketball"), space.GetProperties().GetSpaceName())
+	assert.Equal(t, int16(32), space.GetProperties().GetVidType().GetTypeLength())
+
+	tags := c.GetTags(1)
+	assert.Equal(t, 2, len(tags))
+	assert.Equal(t, int32(2), tags[1].TagID)
+	assert.Equal(t, []byte("player"), tags[1].TagName)
+	assert.Equal(t, int64(1), tags[1].Version)
+	assert.Equal(t, []byte("age"), tags[1].Schema.Columns[0].Name)
+
+	edges := c.GetEdges(1)
+	assert.Equal(t, 1, len(edges))
+	assert.Equal(t, int32(3), edges[0].EdgeType)
+	assert.Equal(t, []byte("follow"), edges[0].EdgeName)
+
+	assert.Equal(t, 1, len(c.GetIndexes(1)))
+	assert.Equal(t, []byte("player_index"), c.GetIndexes(1)[0].IndexName)
+	assert.Nil(t, c.GetSpace(2))
+
+	// invalid keys and values
+	assert.Error(t, c.addSpace(common.NewKV([]byte(spacesKey), b)))
+	assert.Error(t, c.addTag(common.NewKV([]byte(tagsKey), nil)))
+	kv := schemaKV(t, edgesKey, 1, 3, 0, "follow", s)
+	kv.Value = []byte{100, 0, 0, 0}
+	assert.Error(t, c.addEdge(kv))
+}
@@ -49,19 +49,29 @@ func (p *sparceParser) Parse(kv *common.KV) (*common.KVString, error) {
 		spaceDesc.VidType.Type,
 		spaceDesc.VidType.TypeLength,
 	)
+	kvstring.AddField("space", spaceID)
+	kvstring.AddField("name", string(spaceDesc.SpaceName))
+	kvstring.AddField("partition_num", spaceDesc.PartitionNum)
+	kvstring.AddField("replica_factor", spaceDesc.ReplicaFactor)
+	kvstring.AddField("vid_type", spaceDesc.VidType.Type.String())
+	kvstring.AddField("vid_length", spaceDesc.VidType.TypeLength)
 	return kvstring, nil
 }
 
-func (p *sparceParser) Prefix() ([]*common.KV, error) {
+func (p *sparceParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 	if p.opts.SpaceID != -1 {
 		var spaceID []byte
 		if err := common.ConvertIntToBytes(&p.opts.SpaceID, &spaceID, common.ByteOrder); err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, spaceID...)
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *sparceParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
 
 func parseSpaceDesc(v []byte) (*meta.SpaceDesc, error) {
@@ -60,8 +60,10 @@ func (p *tagParser) Parse(kv *common.KV) (*common.KVString, error) {
 		return nil, err
 	}
 	columns := make([]string, 0)
+	names := make([]string, 0)
 	for _, c := range schema.Columns {
 		columns = append(columns, fmt.Sprintf("name:%s;default:%s", string(c.Name), string(c.DefaultValue)))
+		names = append(names, string(c.Name))
 	}
 	kvstring.Value = fmt.Sprintf(
 		"name:%s, columns:%v, ttl column: %s, ttl duration: %d",
@@ -70,6 +72,13 @@ func (p *tagParser) Parse(kv *common.KV) (*common.KVString, error) {
 		string(schema.GetSchemaProp().GetTtlCol()),
 		schema.GetSchemaProp().GetTtlDuration(),
 	)
+	kvstring.AddField("space", spaceID)
+	kvstring.AddField("tag", tagID)
+	kvstring.AddField("version", versionNum)
+	kvstring.AddField("name", string(name))
+	kvstring.AddField("columns", strings.Join(names, ","))
+	kvstring.AddField("ttl_column", string(schema.GetSchemaProp().GetTtlCol()))
+	kvstring.AddField("ttl_duration", schema.GetSchemaProp().GetTtlDuration())
 	return kvstring, nil
 }
 
@@ -82,7 +91,7 @@ func parseSchema(v []byte) (*meta.Schema, error) {
 	return m, nil
 }
 
-func (p *tagParser) Prefix() ([]*common.KV, error) {
+func (p *tagParser) Iterate(fn func(*common.KV) error) error {
 	s := []byte(p.key)
 	var (
 		spaceID []byte
@@ -90,16 +99,20 @@ func (p *tagParser) Prefix() ([]*common.KV, error) {
 	)
 	if p.opts.SpaceID != -1 {
 		if err := common.ConvertIntToBytes(&p.opts.SpaceID, &spaceID, common.ByteOrder); err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, spaceID...)
 
 		if p.opts.TagID != -1 {
 			if err := common.ConvertIntToBytes(&p.opts.TagID, &tagID, common.ByteOrder); err != nil {
-				return nil, err
+				return err
 			}
 			s = append(s, tagID...)
 		}
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *tagParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
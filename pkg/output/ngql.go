@@ -0,0 +1,168 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/storage"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+)
+
+// ngqlWriter writes the tag and edge rows as INSERT statements,
+// the consecutive rows of the same tag or edge and columns are batched into a statement.
+type ngqlWriter struct {
+	w     io.Writer
+	batch int
+	head  string
+	rows  []string
+}
+
+func NewNGQLWriter(w io.Writer, batch int) (Writer, error) {
+	if batch <= 0 {
+		return nil, fmt.Errorf("invalid batch size %d", batch)
+	}
+	return &ngqlWriter{w: w, batch: batch}, nil
+}
+
+func (n *ngqlWriter) Write(kv *common.KVString) error {
+	if kv.Row == nil {
+		common.Logger.Debugf("skip %s, it's not a vertex or an out edge", kv.Key)
+		return nil
+	}
+	row, err := statementRow(kv.Row)
+	if err != nil {
+		return fmt.Errorf("%s, err: %w", kv.Key, err)
+	}
+	head := statementHead(kv.Row)
+	if head != n.head || len(n.rows) == n.batch {
+		if err := n.flush(); err != nil {
+			return err
+		}
+		n.head = head
+	}
+	n.rows = append(n.rows, row)
+	return nil
+}
+
+func (n *ngqlWriter) flush() error {
+	if len(n.rows) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(n.w, "%s %s;\n", n.head, strings.Join(n.rows, ", "))
+	n.rows = n.rows[:0]
+	return err
+}
+
+func (n *ngqlWriter) Close() error {
+	return n.flush()
+}
+
+// statementHead returns e.g. INSERT VERTEX `player`(`name`, `age`) VALUES
+func statementHead(r *common.Row) string {
+	columns := make([]string, 0, len(r.Columns))
+	for _, c := range r.Columns {
+		columns = append(columns, identifier(c))
+	}
+	t := "VERTEX"
+	if r.Edge {
+		t = "EDGE"
+	}
+	return fmt.Sprintf("INSERT %s %s(%s) VALUES", t, identifier(r.Name), strings.Join(columns, ", "))
+}
+
+// statementRow returns e.g. "player100":("Tim", 42) or "player100"->"player101"@0:(95)
+func statementRow(r *common.Row) (string, error) {
+	values, err := ngqlValues(storage.RowValues(r))
+	if err != nil {
+		return "", err
+	}
+	vid, err := ngqlValue(r.Vid)
+	if err != nil {
+		return "", err
+	}
+	if !r.Edge {
+		return fmt.Sprintf("%s:(%s)", vid, values), nil
+	}
+	dst, err := ngqlValue(r.Dst)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s->%s@%d:(%s)", vid, dst, r.Rank, values), nil
+}
+
+func identifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// ngqlString quotes the string, the control bytes are escaped in octal.
+func ngqlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\%03o`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// ngqlValue formats the value as a nGQL literal.
+func ngqlValue(value *nebula.Value) (string, error) {
+	switch {
+	case value.IsSetNVal():
+		return "NULL", nil
+	case value.IsSetBVal(), value.IsSetIVal():
+		return storage.FormatValue(value), nil
+	case value.IsSetFVal():
+		if f := value.GetFVal(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("cannot export the float %v", f)
+		}
+		return storage.FormatValue(value), nil
+	case value.IsSetSVal():
+		return ngqlString(string(value.GetSVal())), nil
+	case value.IsSetDVal():
+		return fmt.Sprintf("date(%q)", storage.FormatValue(value)), nil
+	case value.IsSetTVal():
+		return fmt.Sprintf("time(%q)", storage.FormatValue(value)), nil
+	case value.IsSetDtVal():
+		return fmt.Sprintf("datetime(%q)", storage.FormatValue(value)), nil
+	case value.IsSetGgVal():
+		return fmt.Sprintf("ST_GeogFromText(%q)", storage.FormatValue(value)), nil
+	case value.IsSetDuVal():
+		d := value.GetDuVal()
+		return fmt.Sprintf("duration({months: %d, seconds: %d, microseconds: %d})",
+			d.GetMonths(), d.GetSeconds(), d.GetMicroseconds()), nil
+	default:
+		return "", fmt.Errorf("cannot export the value %s", storage.FormatValue(value))
+	}
+}
+
+func ngqlValues(values []*nebula.Value) (string, error) {
+	r := make([]string, 0, len(values))
+	for _, v := range values {
+		s, err := ngqlValue(v)
+		if err != nil {
+			return "", err
+		}
+		r = append(r, s)
+	}
+	return strings.Join(r, ", "), nil
+}
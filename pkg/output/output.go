@@ -0,0 +1,249 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// output formats
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatTable = "table"
+	FormatNGQL  = "ngql"
+	// csv files and the config of nebula-importer in a directory
+	FormatImporter = "importer"
+)
+
+// DefaultBatchSize is the rows of an INSERT statement.
+const DefaultBatchSize = 100
+
+// rows of a table are aligned in blocks, so the memory doesn't grow with the rows.
+const tableBlockSize = 1000
+
+// Writer writes the decoded kvs one by one, Close must be called after the last one.
+type Writer interface {
+	Write(kv *common.KVString) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "", FormatText:
+		return &textWriter{w: w}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatJSONL:
+		return &jsonlWriter{w: w}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatTable:
+		return &tableWriter{w: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}, nil
+	case FormatNGQL:
+		return NewNGQLWriter(w, DefaultBatchSize)
+	case FormatImporter:
+		return nil, fmt.Errorf("importer output only supports the storage tags and edges")
+	default:
+		return nil, fmt.Errorf("invalid output format %s", format)
+	}
+}
+
+// IsText returns whether the format is the plain text, other formats are machine readable.
+func IsText(format string) bool {
+	return format == "" || format == FormatText
+}
+
+// fields returns the decoded fields, or the key and value if the parser has no fields.
+func fields(kv *common.KVString) []common.Field {
+	if len(kv.Fields) != 0 {
+		return kv.Fields
+	}
+	return []common.Field{{Name: "key", Value: kv.Key}, {Name: "value", Value: kv.Value}}
+}
+
+func names(fs []common.Field) []string {
+	r := make([]string, 0, len(fs))
+	for _, f := range fs {
+		r = append(r, f.Name)
+	}
+	return r
+}
+
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// marshal keeps the order of the fields.
+func marshal(fs []common.Field) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range fs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal %s, err: %w", f.Name, err)
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Write(kv *common.KVString) error {
+	_, err := fmt.Fprintf(t.w, "key: %s, value: %s\n", kv.Key, kv.Value)
+	return err
+}
+
+func (t *textWriter) Close() error {
+	return nil
+}
+
+type jsonlWriter struct {
+	w io.Writer
+}
+
+func (j *jsonlWriter) Write(kv *common.KVString) error {
+	b, err := marshal(fields(kv))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(j.w, "%s\n", b)
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+// jsonWriter writes an array, the elements are written one by one.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(kv *common.KVString) error {
+	b, err := marshal(fields(kv))
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+	_, err = fmt.Fprintf(j.w, "%s%s", sep, b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.count == 0 {
+		_, err := fmt.Fprint(j.w, "[]\n")
+		return err
+	}
+	_, err := fmt.Fprint(j.w, "\n]\n")
+	return err
+}
+
+// csvWriter writes the header again when the fields change, e.g. rows of different tags.
+type csvWriter struct {
+	w      *csv.Writer
+	header []string
+}
+
+func (c *csvWriter) Write(kv *common.KVString) error {
+	fs := fields(kv)
+	if h := names(fs); !sameNames(h, c.header) {
+		c.header = h
+		if err := c.w.Write(h); err != nil {
+			return err
+		}
+	}
+	record := make([]string, 0, len(fs))
+	for _, f := range fs {
+		record = append(record, toString(f.Value))
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// tableWriter writes the header again when the fields change.
+type tableWriter struct {
+	w      *tabwriter.Writer
+	header []string
+	rows   int
+}
+
+func (t *tableWriter) Write(kv *common.KVString) error {
+	fs := fields(kv)
+	if h := names(fs); !sameNames(h, t.header) || t.rows == tableBlockSize {
+		if t.header != nil {
+			if err := t.w.Flush(); err != nil {
+				return err
+			}
+		}
+		t.header, t.rows = h, 0
+		if _, err := fmt.Fprintf(t.w, "%s\n", strings.ToUpper(strings.Join(h, "\t"))); err != nil {
+			return err
+		}
+	}
+	values := make([]string, 0, len(fs))
+	for _, f := range fs {
+		// tab and newline break the table
+		values = append(values, strings.NewReplacer("\t", "\\t", "\n", "\\n").Replace(toString(f.Value)))
+	}
+	t.rows++
+	_, err := fmt.Fprintf(t.w, "%s\n", strings.Join(values, "\t"))
+	return err
+}
+
+func (t *tableWriter) Close() error {
+	return t.w.Flush()
+}
@@ -0,0 +1,162 @@
+package output
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+)
+
+func newKVStrings() []*common.KVString {
+	tag := &common.KVString{Key: "part:1, vid:1, tag:2", Value: "version:0, name:\"a,b\", timestamp:1"}
+	tag.AddField("part", int32(1))
+	tag.AddField("vid", "1")
+	tag.AddField("name", "a,b")
+	tag.AddField("age", nil)
+	edge := &common.KVString{Key: "part:1, src:1, edge:3, dst:2, rank:0", Value: ""}
+	edge.AddField("part", int32(1))
+	edge.AddField("src", "1")
+	return []*common.KVString{tag, tag, edge, {Key: "k", Value: "v"}}
+}
+
+func write(t *testing.T, format string) string {
+	var b bytes.Buffer
+	w, err := NewWriter(format, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range newKVStrings() {
+		assert.NoError(t, w.Write(kv))
+	}
+	assert.NoError(t, w.Close())
+	return b.String()
+}
+
+func TestWriter(t *testing.T) {
+	assert.Equal(t, `key: part:1, vid:1, tag:2, value: version:0, name:"a,b", timestamp:1
+key: part:1, vid:1, tag:2, value: version:0, name:"a,b", timestamp:1
+key: part:1, src:1, edge:3, dst:2, rank:0, value: 
+key: k, value: v
+`, write(t, FormatText))
+
+	assert.Equal(t, `{"part":1,"vid":"1","name":"a,b","age":null}
+{"part":1,"vid":"1","name":"a,b","age":null}
+{"part":1,"src":"1"}
+{"key":"k","value":"v"}
+`, write(t, FormatJSONL))
+
+	assert.Equal(t, `[
+{"part":1,"vid":"1","name":"a,b","age":null},
+{"part":1,"vid":"1","name":"a,b","age":null},
+{"part":1,"src":"1"},
+{"key":"k","value":"v"}
+]
+`, write(t, FormatJSON))
+
+	assert.Equal(t, `part,vid,name,age
+1,1,"a,b",
+1,1,"a,b",
+part,src
+1,1
+key,value
+k,v
+`, write(t, FormatCSV))
+
+	assert.Equal(t, `PART  VID  NAME  AGE
+1     1    a,b   
+1     1    a,b   
+PART  SRC
+1     1
+KEY  VALUE
+k    v
+`, write(t, FormatTable))
+
+	_, err := NewWriter("xml", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestEmptyJSON(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(FormatJSON, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "[]\n", b.String())
+}
+
+func TestNGQLWriter(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	var b bytes.Buffer
+	w, err := NewNGQLWriter(&b, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := func(s string) *nebula.Value {
+		return &nebula.Value{SVal: []byte(s)}
+	}
+	null, degree := nebula.NullType___NULL__, int64(95)
+	player := func(vid, name string) *common.KVString {
+		return &common.KVString{Row: &common.Row{Name: "player", Vid: str(vid), Columns: []string{"name", "age"}, Values: []*nebula.Value{str(name), {NVal: &null}}}}
+	}
+	follow := &common.KVString{Row: &common.Row{Edge: true, Name: "follow", Vid: str("a"), Dst: str("b"), Rank: -1, Columns: []string{"degree"}, Values: []*nebula.Value{{IVal: &degree}}}}
+	for _, kv := range []*common.KVString{
+		player("a", "Tim"), player("b", "Tony"), player("c", `Ma"nu`),
+		{Key: "part:1, src:b, edge:-3, dst:a, rank:-1"},
+		follow,
+	} {
+		assert.NoError(t, w.Write(kv))
+	}
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "INSERT VERTEX `player`(`name`, `age`) VALUES \"a\":(\"Tim\", NULL), \"b\":(\"Tony\", NULL);\n"+
+		"INSERT VERTEX `player`(`name`, `age`) VALUES \"c\":(\"Ma\\\"nu\", NULL);\n"+
+		"INSERT EDGE `follow`(`degree`) VALUES \"a\"->\"b\"@-1:(95);\n", b.String())
+
+	// the int vid is not quoted, and the row of NaN is not exported
+	b.Reset()
+	nan := math.NaN()
+	assert.Error(t, w.Write(&common.KVString{Row: &common.Row{Name: "player", Vid: &nebula.Value{IVal: &degree}, Values: []*nebula.Value{{FVal: &nan}}}}))
+	assert.NoError(t, w.Write(&common.KVString{Row: &common.Row{Name: "player", Vid: &nebula.Value{IVal: &degree}, Columns: []string{"age"}, Values: []*nebula.Value{{IVal: &degree}}}}))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "INSERT VERTEX `player`(`age`) VALUES 95:(95);\n", b.String())
+
+	// the padding of the fixed string is trimmed
+	b.Reset()
+	assert.NoError(t, w.Write(&common.KVString{Row: &common.Row{
+		Name: "team", Vid: str("a"), Columns: []string{"code", "name"},
+		Types:  []nebula.PropertyType{nebula.PropertyType_FIXED_STRING, nebula.PropertyType_STRING},
+		Values: []*nebula.Value{str("SAS\x00\x00\x00\x00\x00"), str("Spurs\x00")},
+	}}))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "INSERT VERTEX `team`(`code`, `name`) VALUES \"a\":(\"SAS\", \"Spurs\\000\");\n", b.String())
+
+	_, err = NewNGQLWriter(&b, 0)
+	assert.Error(t, err)
+}
+
+func TestNGQLValue(t *testing.T) {
+	date := nebula.NewDate()
+	date.Year, date.Month, date.Day = 2022, 1, 2
+	null := nebula.NullType___NULL__
+	f, nan := 1.0, math.NaN()
+	for expected, v := range map[string]*nebula.Value{
+		"NULL":               {NVal: &null},
+		"1.0":                {FVal: &f},
+		`"a\\b\n"`:           {SVal: []byte("a\\b\n")},
+		`date("2022-01-02")`: {DVal: date},
+		`"a\001\177"`:        {SVal: []byte("a\x01\x7f")},
+	} {
+		s, err := ngqlValue(v)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s)
+	}
+	_, err := ngqlValue(&nebula.Value{FVal: &nan})
+	assert.Error(t, err)
+	// nebula has no list property
+	_, err = ngqlValue(&nebula.Value{LVal: &nebula.NList{Values: []*nebula.Value{{SVal: []byte("a")}}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package schemacache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// CacheInfo is the schema cache of a meta address.
+type CacheInfo struct {
+	Address        string
+	LastUpdateTime string
+	Spaces         int
+}
+
+// ListCaches returns the schema caches of all meta addresses, sorted by the address.
+func ListCaches() ([]*CacheInfo, error) {
+	dir := cacheDir()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	caches := make([]*CacheInfo, 0, len(entries))
+	for _, e := range entries {
+		file := filepath.Join(dir, e.Name(), defaultName)
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		info := &CacheInfo{Address: e.Name()}
+		// keep the broken cache in the list, so that it could be cleared
+		d, err := readCacheData(file)
+		if err != nil {
+			common.Logger.Warn(err)
+		} else {
+			info.LastUpdateTime, info.Spaces = d.LastUpdateTime, len(d.Spaces)
+		}
+		caches = append(caches, info)
+	}
+	sort.Slice(caches, func(i, j int) bool { return caches[i].Address < caches[j].Address })
+	return caches, nil
+}
+
+// RemoveCache removes the schema cache of the meta address.
+func RemoveCache(address string) error {
+	if address == "" || address == "." || address == ".." || filepath.Base(address) != address {
+		return fmt.Errorf("invalid meta address %s", address)
+	}
+	dir := filepath.Join(cacheDir(), address)
+	if _, err := os.Stat(filepath.Join(dir, defaultName)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("there is no schema cache of %s", address)
+		}
+		return err
+	}
+	return os.RemoveAll(dir)
+}
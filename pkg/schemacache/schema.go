@@ -1,12 +1,15 @@
 package schemacache
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
 	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
 	"gopkg.in/yaml.v3"
 )
@@ -30,8 +33,13 @@ type (
 		edges   map[int32][]*meta.EdgeItem
 		indexes map[int32][]*meta.IndexItem
 		client  *common.MetaClient
+		// the requests to metad, it's the client in normal
+		service metaService
 		rwMutex sync.RWMutex
 		address string
+		// the cache is loaded from the file instead of metad
+		offline        bool
+		lastUpdateTime string
 	}
 
 	CacheData struct {
@@ -58,6 +66,16 @@ type (
 	}
 )
 
+// metaService is the part of the meta client used to update the cache.
+type metaService interface {
+	ListSpaces(req *meta.ListSpacesReq) (*meta.ListSpacesResp, error)
+	GetSpace(req *meta.GetSpaceReq) (*meta.GetSpaceResp, error)
+	ListTags(req *meta.ListTagsReq) (*meta.ListTagsResp, error)
+	ListEdges(req *meta.ListEdgesReq) (*meta.ListEdgesResp, error)
+	ListTagIndexes(req *meta.ListTagIndexesReq) (*meta.ListTagIndexesResp, error)
+	ListEdgeIndexes(req *meta.ListEdgeIndexesReq) (*meta.ListEdgeIndexesResp, error)
+}
+
 var _ Schemacache = &FileCache{}
 
 const (
@@ -66,27 +84,93 @@ const (
 )
 
 func NewFileCache(address string) (Schemacache, error) {
-
-	c := &FileCache{}
 	client, err := common.NewMetaClient(address)
 	if err != nil {
 		return nil, err
 	}
+	c := newFileCache(address)
 	c.client = client
-	h, err := os.UserHomeDir()
+	c.service = client.Client
+	return c, nil
+}
+
+// NewOfflineCache loads the cache of the address saved by the last run, it never connects to metad.
+func NewOfflineCache(address string) (*FileCache, error) {
+	c := newFileCache(address)
+	if err := c.loadFile(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadFile switches the cache to offline, and loads the schema saved by the last run.
+func (c *FileCache) loadFile() error {
+	if _, err := os.Stat(c.file()); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("there is no schema cache of %s", c.address)
+		}
+		return err
+	}
+	if err := c.readFromFile(); err != nil {
+		return err
+	}
+	c.offline = true
+	return nil
+}
+
+func (c *FileCache) warnOffline() {
+	common.Logger.Warnf("the schema is loaded from %s, it may be outdated, last update time is %s", c.file(), c.lastUpdateTime)
+}
+
+// LoadFileCache returns the cache connected to metad, or the cache saved by the last run
+// when offline or metad is unreachable.
+func LoadFileCache(address string, offline bool) (Schemacache, error) {
+	if !offline {
+		c, err := NewFileCache(address)
+		if err == nil {
+			return c, nil
+		}
+		if _, statErr := os.Stat(newFileCache(address).file()); statErr != nil {
+			return nil, err
+		}
+		common.Logger.Warnf("cannot connect to meta %s, use the schema cache instead, err: %v", address, err)
+	}
+	c, err := NewOfflineCache(address)
 	if err != nil {
-		c.path = defaultPath
-	} else {
-		c.path = filepath.Join(h, defaultPath)
+		return nil, err
 	}
-	c.name = defaultName
-	c.address = address
-	c.spaces = make(map[int32]*meta.SpaceItem)
-	c.tags = make(map[int32][]*meta.TagItem)
-	c.edges = make(map[int32][]*meta.EdgeItem)
-	c.indexes = make(map[int32][]*meta.IndexItem)
+	c.warnOffline()
 	return c, nil
+}
 
+func newFileCache(address string) *FileCache {
+	return &FileCache{
+		path:    cacheDir(),
+		name:    defaultName,
+		address: address,
+		spaces:  make(map[int32]*meta.SpaceItem),
+		tags:    make(map[int32][]*meta.TagItem),
+		edges:   make(map[int32][]*meta.EdgeItem),
+		indexes: make(map[int32][]*meta.IndexItem),
+	}
+}
+
+// cacheDir returns the dir of the caches, a sub dir per meta address.
+func cacheDir() string {
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return defaultPath
+	}
+	return filepath.Join(h, defaultPath)
+}
+
+// LastUpdateTime returns the time when the cache was updated from metad.
+func (c *FileCache) LastUpdateTime() string {
+	return c.lastUpdateTime
+}
+
+func (c *FileCache) file() string {
+	return filepath.Join(c.path, c.address, c.name)
 }
 
 func (c *FileCache) Close() error {
@@ -97,12 +181,22 @@ func (c *FileCache) Close() error {
 }
 
 func (c *FileCache) Update() error {
+	// the offline cache is loaded from the file once
+	if c.offline {
+		return nil
+	}
 	// get the last update time from meta.
 	// if the time is same, return directly
 
-	// update and flush schema
+	// update and flush schema, the saved cache is only overwritten by a complete schema.
+	// when meta cannot give the schema, e.g. there is no leader, the saved cache is used.
 	if err := c.updateSchema(); err != nil {
-		return err
+		if loadErr := c.loadFile(); loadErr != nil {
+			return err
+		}
+		common.Logger.Warnf("cannot get the schema from meta %s, use the schema cache instead, err: %v", c.address, err)
+		c.warnOffline()
+		return nil
 	}
 
 	if err := c.writeToFile(); err != nil {
@@ -111,50 +205,73 @@ func (c *FileCache) Update() error {
 	return nil
 }
 
+// updateSchema gets the schema of all spaces from meta, the cache is unchanged if any request fails.
 func (c *FileCache) updateSchema() error {
-	c.rwMutex.Lock()
-	defer c.rwMutex.Unlock()
+	var (
+		spaces  = make(map[int32]*meta.SpaceItem)
+		tags    = make(map[int32][]*meta.TagItem)
+		edges   = make(map[int32][]*meta.EdgeItem)
+		indexes = make(map[int32][]*meta.IndexItem)
+	)
 	req := meta.NewListSpacesReq()
-	resp, err := c.client.Client.ListSpaces(req)
-	if err != nil {
+	resp, err := c.service.ListSpaces(req)
+	if err := checkResp("list spaces", resp, err); err != nil {
 		return err
 	}
+	// a meta without leader may answer nothing, never take it as a cluster without space
+	if len(resp.GetSpaces()) == 0 {
+		return fmt.Errorf("list spaces, meta returns no space")
+	}
 	for _, space := range resp.GetSpaces() {
-		id := *space.GetId().SpaceID
+		id := space.GetId().GetSpaceID()
 		spaceReq := meta.NewGetSpaceReq().SetSpaceName(space.GetName())
-		spaceResp, err := c.client.Client.GetSpace(spaceReq)
-		if err != nil {
+		spaceResp, err := c.service.GetSpace(spaceReq)
+		if err := checkResp("get space", spaceResp, err); err != nil {
 			return err
 		}
-		c.spaces[id] = spaceResp.GetItem()
+		spaces[id] = spaceResp.GetItem()
 
 		tagReq := meta.NewListTagsReq().SetSpaceID(id)
-		tagResp, err := c.client.Client.ListTags(tagReq)
-		if err != nil {
+		tagResp, err := c.service.ListTags(tagReq)
+		if err := checkResp("list tags", tagResp, err); err != nil {
 			return err
 		}
-		c.tags[id] = tagResp.Tags
+		tags[id] = tagResp.Tags
 
 		edgeReq := meta.NewListEdgesReq().SetSpaceID(id)
-		edgeResp, err := c.client.Client.ListEdges(edgeReq)
-		if err != nil {
+		edgeResp, err := c.service.ListEdges(edgeReq)
+		if err := checkResp("list edges", edgeResp, err); err != nil {
 			return err
 		}
-		c.edges[id] = edgeResp.Edges
+		edges[id] = edgeResp.Edges
 
 		indexTagReq := meta.NewListTagIndexesReq().SetSpaceID(id)
-		indexTagResp, err := c.client.Client.ListTagIndexes(indexTagReq)
-		if err != nil {
+		indexTagResp, err := c.service.ListTagIndexes(indexTagReq)
+		if err := checkResp("list tag indexes", indexTagResp, err); err != nil {
 			return err
 		}
 
 		indexEdgeReq := meta.NewListEdgeIndexesReq().SetSpaceID(id)
-		indexEdgeResp, err := c.client.Client.ListEdgeIndexes(indexEdgeReq)
-		if err != nil {
+		indexEdgeResp, err := c.service.ListEdgeIndexes(indexEdgeReq)
+		if err := checkResp("list edge indexes", indexEdgeResp, err); err != nil {
 			return err
 		}
-		c.indexes[id] = indexTagResp.Items
-		c.indexes[id] = append(c.indexes[id], indexEdgeResp.Items...)
+		indexes[id] = indexTagResp.Items
+		indexes[id] = append(indexes[id], indexEdgeResp.Items...)
+	}
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+	c.spaces, c.tags, c.edges, c.indexes = spaces, tags, edges, indexes
+	return nil
+}
+
+// checkResp checks the error and the code of the response, resp is nil when err is not nil.
+func checkResp(name string, resp interface{ GetCode() nebula.ErrorCode }, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s, err: %w", name, err)
+	}
+	if code := resp.GetCode(); code != nebula.ErrorCode_SUCCEEDED {
+		return fmt.Errorf("%s, code: %s", name, code)
 	}
 	return nil
 }
@@ -256,8 +373,12 @@ func (c *FileCache) convertToData() (*CacheData, error) {
 }
 
 func (c *FileCache) convertFromData(d *CacheData) error {
-	c.rwMutex.RLock()
-	defer c.rwMutex.RUnlock()
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+	c.spaces = make(map[int32]*meta.SpaceItem)
+	c.tags = make(map[int32][]*meta.TagItem)
+	c.edges = make(map[int32][]*meta.EdgeItem)
+	c.indexes = make(map[int32][]*meta.IndexItem)
 
 	for _, spaceData := range d.Spaces {
 		id := spaceData.Id
@@ -301,29 +422,35 @@ func (c *FileCache) convertFromData(d *CacheData) error {
 }
 
 func (c *FileCache) readFromFile() error {
-	file := filepath.Join(c.path, c.address, c.name)
-	if _, err := os.Stat(file); err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
+	d, err := readCacheData(c.file())
+	if err != nil {
+		return err
+	}
+	if err := c.convertFromData(d); err != nil {
 		return err
 	}
+	c.lastUpdateTime = d.LastUpdateTime
+	// written by the old versions
+	if c.lastUpdateTime == "" {
+		c.lastUpdateTime = "unknown"
+	}
+	return nil
+}
+
+func readCacheData(file string) (*CacheData, error) {
 	in, err := ioutil.ReadFile(file)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var d CacheData
 	if err := yaml.Unmarshal(in, &d); err != nil {
-		return err
+		return nil, fmt.Errorf("invalid schema cache %s, err: %w", file, err)
 	}
-	if err := c.convertFromData(&d); err != nil {
-		return err
-	}
-	return nil
+	return &d, nil
 }
 
 func (c *FileCache) writeToFile() error {
-	file := filepath.Join(c.path, c.address, c.name)
+	file := c.file()
 	dir := filepath.Dir(file)
 	if _, err := os.Stat(dir); err != nil {
 		if err := os.MkdirAll(dir, os.ModeDir|os.ModePerm); err != nil {
@@ -334,6 +461,8 @@ func (c *FileCache) writeToFile() error {
 	if err != nil {
 		return err
 	}
+	d.LastUpdateTime = time.Now().Format(time.RFC3339)
+	c.lastUpdateTime = d.LastUpdateTime
 	out, err := yaml.Marshal(&d)
 	if err != nil {
 		return err
@@ -0,0 +1,163 @@
+package schemacache
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+func TestOfflineCache(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	home, err := ioutil.TempDir("", "meta_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	// metad is unreachable and there is no cache
+	address := "127.0.0.1:1"
+	_, err = LoadFileCache(address, false)
+	assert.Error(t, err)
+	_, err = LoadFileCache(address, true)
+	assert.Error(t, err)
+
+	c := newFileCache(address)
+	desc := meta.NewSpaceDesc()
+	desc.SpaceName = []byte("basketball")
+	c.spaces[1] = &meta.SpaceItem{SpaceID: 1, Properties: desc}
+	c.tags[1] = []*meta.TagItem{{TagID: 2, TagName: []byte("player"), Version: 1, Schema: meta.NewSchema()}}
+	c.edges[1] = []*meta.EdgeItem{{EdgeType: 3, EdgeName: []byte("follow"), Schema: meta.NewSchema()}}
+	c.indexes[1] = []*meta.IndexItem{{IndexID: 4, IndexName: []byte("player_index")}}
+	if err := c.writeToFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offline := range []bool{true, false} {
+		schema, err := LoadFileCache(address, offline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, schema.Update())
+		assert.Equal(t, []int32{1}, schema.ListSpaces())
+		assert.Equal(t, []byte("basketball"), schema.GetSpace(1).GetProperties().GetSpaceName())
+		assert.Equal(t, 1, len(schema.GetTags(1)))
+		assert.Equal(t, int64(1), schema.GetTags(1)[0].GetVersion())
+		assert.Equal(t, []byte("follow"), schema.GetEdges(1)[0].GetEdgeName())
+		assert.Equal(t, int32(4), schema.GetIndexes(1)[0].GetIndexID())
+		assert.Equal(t, c.LastUpdateTime(), schema.(*FileCache).LastUpdateTime())
+	}
+
+	caches, err := ListCaches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*CacheInfo{{Address: address, LastUpdateTime: c.LastUpdateTime(), Spaces: 1}}, caches)
+
+	assert.Error(t, RemoveCache(".."))
+	assert.Error(t, RemoveCache("127.0.0.1:2"))
+	assert.NoError(t, RemoveCache(address))
+	caches, err = ListCaches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, caches)
+	_, err = NewOfflineCache(address)
+	assert.Error(t, err)
+}
+
+// fakeMeta answers the space basketball, or fails with the code or the error.
+type fakeMeta struct {
+	code    nebula.ErrorCode
+	tagsErr error
+}
+
+func (m *fakeMeta) ListSpaces(req *meta.ListSpacesReq) (*meta.ListSpacesResp, error) {
+	resp := &meta.ListSpacesResp{Code: m.code}
+	if m.code == nebula.ErrorCode_SUCCEEDED {
+		id := nebula.GraphSpaceID(1)
+		resp.Spaces = []*meta.IdName{{Id: &meta.ID{SpaceID: &id}, Name: []byte("basketball")}}
+	}
+	return resp, nil
+}
+
+func (m *fakeMeta) GetSpace(req *meta.GetSpaceReq) (*meta.GetSpaceResp, error) {
+	desc := meta.NewSpaceDesc()
+	desc.SpaceName = req.GetSpaceName()
+	return &meta.GetSpaceResp{Item: &meta.SpaceItem{SpaceID: 1, Properties: desc}}, nil
+}
+
+func (m *fakeMeta) ListTags(req *meta.ListTagsReq) (*meta.ListTagsResp, error) {
+	if m.tagsErr != nil {
+		return nil, m.tagsErr
+	}
+	return &meta.ListTagsResp{Tags: []*meta.TagItem{{TagID: 2, TagName: []byte("player"), Schema: meta.NewSchema()}}}, nil
+}
+
+func (m *fakeMeta) ListEdges(req *meta.ListEdgesReq) (*meta.ListEdgesResp, error) {
+	return &meta.ListEdgesResp{}, nil
+}
+
+func (m *fakeMeta) ListTagIndexes(req *meta.ListTagIndexesReq) (*meta.ListTagIndexesResp, error) {
+	return &meta.ListTagIndexesResp{}, nil
+}
+
+func (m *fakeMeta) ListEdgeIndexes(req *meta.ListEdgeIndexesReq) (*meta.ListEdgeIndexesResp, error) {
+	return &meta.ListEdgeIndexesResp{}, nil
+}
+
+func TestUpdateFallback(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	home, err := ioutil.TempDir("", "meta_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	address := "127.0.0.1:9559"
+	m := &fakeMeta{code: nebula.ErrorCode_E_LEADER_CHANGED}
+	c := newFileCache(address)
+	c.service = m
+	// no cache to fall back to
+	assert.Error(t, c.Update())
+
+	m.code = nebula.ErrorCode_SUCCEEDED
+	if err := c.Update(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int32{1}, c.ListSpaces())
+	saved, err := ioutil.ReadFile(c.file())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// meta without leader answers no space, or a request fails after the dial,
+	// the saved cache is used and never overwritten
+	for _, fail := range []*fakeMeta{
+		{code: nebula.ErrorCode_E_LEADER_CHANGED},
+		{code: nebula.ErrorCode_SUCCEEDED, tagsErr: errors.New("timeout")},
+	} {
+		c := newFileCache(address)
+		c.service = fail
+		if err := c.Update(); err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, c.offline)
+		assert.Equal(t, []byte("basketball"), c.GetSpace(1).GetProperties().GetSpaceName())
+		assert.Equal(t, 1, len(c.GetTags(1)))
+		b, err := ioutil.ReadFile(c.file())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, saved, b)
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+)
+
+// KeyDecoder decodes the keys of all key types with the storage parsers.
+// it is used for the keys which are not read from rocksdb, e.g. in the raft wal.
+type KeyDecoder struct {
+	opts   *pkg.Option
+	schema schemacache.Schemacache
+}
+
+func NewKeyDecoder(opts *pkg.Option) (*KeyDecoder, error) {
+	if err := verifyOption(opts); err != nil {
+		return nil, err
+	}
+	schema, err := NewSchemaCache(opts)
+	if err != nil {
+		return nil, err
+	}
+	if schema.GetSpace(opts.SpaceID) == nil {
+		return nil, fmt.Errorf("cannot find the space")
+	}
+	return newKeyDecoder(opts, schema), nil
+}
+
+func newKeyDecoder(opts *pkg.Option, schema schemacache.Schemacache) *KeyDecoder {
+	o := *opts
+	// the decoder has no engine to join the tags, and keeps all rows
+	o.WithTags = false
+	o.TTL = pkg.TTLInclude
+	return &KeyDecoder{opts: &o, schema: schema}
+}
+
+// Decode decodes the key and the value, value is nil for the removed keys.
+func (d *KeyDecoder) Decode(key, value []byte) (*common.KVString, error) {
+	var item int32
+	if len(key) < common.Sizeof(item) {
+		return nil, fmt.Errorf("invalid key, length is %d", len(key))
+	}
+	t := key[:common.Sizeof(item)]
+	if err := common.ConvertBytesToInt(&item, &t, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	kv := common.NewKV(key, value)
+	switch item & 0xff {
+	case kTag, kEdge, kPrime, kDoublePrime:
+		var (
+			parse    func(*common.KV) (*common.KVString, error)
+			parseKey func([]byte) (*common.KVString, int32, error)
+		)
+		if item&0xff == kTag {
+			p := &tagParser{opts: d.opts, schema: d.schema}
+			parse, parseKey = p.Parse, p.parseKey
+		} else {
+			p := &edgeParser{opts: d.opts, schema: d.schema}
+			parse, parseKey = p.Parse, p.parseKey
+		}
+		if value != nil {
+			kvstring, err := parse(kv)
+			if err != nil || kvstring != nil {
+				return kvstring, err
+			}
+		}
+		// the removed key, or the row skipped by the parser
+		kvstring, _, err := parseKey(key)
+		return kvstring, err
+	case kIndex:
+		var indexID int32
+		if len(key) < common.Sizeof(item)+common.Sizeof(indexID) {
+			return nil, fmt.Errorf("invalid index key, length is %d", len(key))
+		}
+		i := key[common.Sizeof(item) : common.Sizeof(item)+common.Sizeof(indexID)]
+		if err := common.ConvertBytesToInt(&indexID, &i, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		index := getIndexItem(d.schema, d.opts.SpaceID, indexID)
+		if index == nil {
+			return nil, fmt.Errorf("cannot find the index %d", indexID)
+		}
+		p := &indexParser{opts: d.opts, schema: d.schema}
+		p.setIndex(index)
+		return p.Parse(kv)
+	case kSystem:
+		return (&systemParser{opts: d.opts}).Parse(kv)
+	case kOperation:
+		return (&operationParser{opts: d.opts, schema: d.schema}).Parse(kv)
+	case kKeyValue:
+		return (&kvParser{opts: d.opts}).Parse(kv)
+	case kVertex:
+		return (&vertexParser{opts: d.opts, schema: d.schema}).Parse(kv)
+	default:
+		return &common.KVString{
+			Key:   fmt.Sprintf("type:%d, key:%s", item&0xff, hex.EncodeToString(key)),
+			Value: hex.EncodeToString(value),
+		}, nil
+	}
+}
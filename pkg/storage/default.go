@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+// default value of a column is an encoded expression.
+// constant expression: kind(1byte) + value type(8byte) + value
+const (
+	exprKindConstant uint8 = 0
+
+	valueTypeBool     uint64 = 1 << 1
+	valueTypeInt      uint64 = 1 << 2
+	valueTypeFloat    uint64 = 1 << 3
+	valueTypeString   uint64 = 1 << 4
+	valueTypeDate     uint64 = 1 << 5
+	valueTypeTime     uint64 = 1 << 6
+	valueTypeDateTime uint64 = 1 << 7
+	valueTypeNull     uint64 = 1 << 63
+)
+
+// ColumnDefault decodes the default value of the column, nil if the column has no default.
+func ColumnDefault(c *meta.ColumnDef) (*nebula.Value, error) {
+	if !c.IsSetDefaultValue() {
+		return nil, nil
+	}
+	return decodeDefaultValue(c.GetDefaultValue())
+}
+
+// decodeDefaultValue only supports constant expressions,
+// others like now() cannot be evaluated offline.
+func decodeDefaultValue(b []byte) (*nebula.Value, error) {
+	var valueType uint64
+	if len(b) < 1+8 || b[0] != exprKindConstant {
+		return nil, fmt.Errorf("not a constant expression")
+	}
+	t, data := b[1:1+8], b[1+8:]
+	if err := common.ConvertBytesToInt(&valueType, &t, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	switch valueType {
+	case valueTypeNull:
+		return newNullValue(nebula.NullType___NULL__), nil
+	case valueTypeBool:
+		return GetValue(data, nebula.PropertyType_BOOL)
+	case valueTypeInt:
+		return GetValue(data, nebula.PropertyType_INT64)
+	case valueTypeFloat:
+		return GetValue(data, nebula.PropertyType_DOUBLE)
+	case valueTypeString:
+		// length(8byte) + string
+		var l int64
+		if len(data) < 8 {
+			return nil, fmt.Errorf("invalid string expression")
+		}
+		lb := data[:8]
+		if err := common.ConvertBytesToInt(&l, &lb, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		if l < 0 || int(8+l) > len(data) {
+			return nil, fmt.Errorf("invalid string expression, length is %d", l)
+		}
+		return GetValue(data[8:8+l], nebula.PropertyType_STRING)
+	case valueTypeDate:
+		return GetValue(data, nebula.PropertyType_DATE)
+	case valueTypeTime:
+		// the c++ struct is copied with the padding, microsec is aligned to offset 4
+		// struct Time: hour(1byte) + minute(1byte) + sec(1byte) + padding(1byte) + microsec(4byte)
+		if len(data) < 8 {
+			return nil, fmt.Errorf("invalid time expression")
+		}
+		return GetValue(unpad(data[:8], 3, 1), nebula.PropertyType_TIME)
+	case valueTypeDateTime:
+		// struct DateTime: year(2byte) + month(1byte) + day(1byte) + hour(1byte) + minute(1byte) + sec(1byte)
+		// + padding(1byte) + microsec(4byte)
+		if len(data) < 12 {
+			return nil, fmt.Errorf("invalid datetime expression")
+		}
+		return GetValue(unpad(data[:12], 7, 1), nebula.PropertyType_DATETIME)
+	default:
+		return nil, fmt.Errorf("not support this value type: %d", valueType)
+	}
+}
+
+// unpad removes the padding of n bytes at offset, the row packs the fields without padding.
+func unpad(b []byte, offset, n int) []byte {
+	r := make([]byte, 0, len(b)-n)
+	r = append(r, b[:offset]...)
+	return append(r, b[offset+n:]...)
+}
+
+// fillMissingColumns appends the columns which are added after the row is written,
+// follow nebula, use the default value first, then null. returns the types of the appended columns.
+func fillMissingColumns(ds *nebula.DataSet, row *nebula.Row, latest *meta.Schema) []nebula.PropertyType {
+	var types []nebula.PropertyType
+	exists := make(map[string]struct{}, len(ds.ColumnNames))
+	for _, name := range ds.ColumnNames {
+		exists[string(name)] = struct{}{}
+	}
+	for _, c := range latest.GetColumns() {
+		if _, ok := exists[string(c.GetName())]; ok {
+			continue
+		}
+		var v *nebula.Value
+		if c.IsSetDefaultValue() {
+			d, err := decodeDefaultValue(c.GetDefaultValue())
+			if err != nil {
+				common.Logger.Debugf("cannot decode the default value of %s, err: %v", c.GetName(), err)
+				d = newNullValue(nebula.NullType_UNKNOWN_PROP)
+			}
+			v = d
+		} else if c.GetNullable() {
+			v = newNullValue(nebula.NullType___NULL__)
+		} else {
+			v = newNullValue(nebula.NullType_UNKNOWN_PROP)
+		}
+		ds.ColumnNames = append(ds.ColumnNames, c.GetName())
+		row.Values = append(row.Values, v)
+		types = append(types, c.GetType().GetType())
+	}
+	return types
+}
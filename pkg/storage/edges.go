@@ -5,13 +5,18 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
-	"strings"
 
 	"github.com/harrischu/nebula-dump/pkg"
 	"github.com/harrischu/nebula-dump/pkg/common"
 	"github.com/harrischu/nebula-dump/pkg/schemacache"
 )
 
+// versions in the last byte of edge key
+const (
+	edgeLockVersion byte = 0
+	edgeVersion     byte = 1
+)
+
 // edgeParser
 // key: (type + part) + src + edge type(4bit) + dst
 // value: versionLength + version + value
@@ -30,6 +35,43 @@ func (p *edgeParser) New(engine *common.Engine, opts *pkg.Option) pkg.Parser {
 }
 
 func (p *edgeParser) Parse(kv *common.KV) (*common.KVString, error) {
+	kvstring, edgeType, err := p.parseKey(kv.Key)
+	if err != nil {
+		return nil, err
+	}
+	// the value of prime key is the request of the chained edge
+	if t := kv.Key[0]; int32(t) == kPrime || int32(t) == kDoublePrime {
+		kvstring.Value = fmt.Sprintf("length:%d", len(kv.Value))
+		kvstring.AddField("length", len(kv.Value))
+		return kvstring, nil
+	}
+
+	id := int32(math.Abs(float64(edgeType)))
+	rowData, err := decodeValue("edge", kv.Value, p.opts.SpaceID, id, p.schema, p.opts.MissingSchema)
+	if err != nil {
+		if skipMissingSchema(err, p.opts.MissingSchema) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !filterTTL("edge", id, rowData, p.schema, p.opts) {
+		return nil, nil
+	}
+	kvstring.Value = formatRowData(rowData)
+	addRowFields(kvstring, rowData)
+	if kvstring.Row != nil {
+		if err := setRowValues(kvstring.Row, "edge", p.opts.SpaceID, id, rowData, p.schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return kvstring, nil
+}
+
+// parseKey decodes the edge key and the prime key of the chained edge, and returns the edge type.
+// the last byte of edge key is the edge version, it's 0 for the lock of the chained edge in nebula 2.x.
+// prime key: (type + part) + src + edge type(4bit) + rank(8bit) + dst + edge version
+func (p *edgeParser) parseKey(key []byte) (*common.KVString, int32, error) {
 	var (
 		kvstring  = &common.KVString{}
 		partID    int32
@@ -41,142 +83,168 @@ func (p *edgeParser) Parse(kv *common.KV) (*common.KVString, error) {
 		rank      uint64
 	)
 	vidLength = p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().TypeLength
-	n := len(kv.Key) - 1
+	n := common.Sizeof(partID) + int(vidLength) + common.Sizeof(edgeType) + common.Sizeof(rank) + int(vidLength)
+	if len(key) != n+1 {
+		return nil, 0, fmt.Errorf("invalid edge key, length is %d", len(key))
+	}
 
-	pt, l, e, k, r := kv.Key[:common.Sizeof(partID)],
-		kv.Key[common.Sizeof(partID):common.Sizeof(partID)+int(vidLength)],
-		kv.Key[common.Sizeof(partID)+int(vidLength):common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType)],
-		kv.Key[common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType):common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType)+common.Sizeof(rank)],
-		kv.Key[common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType)+common.Sizeof(rank):n]
+	pt, l, e, k, r := key[:common.Sizeof(partID)],
+		key[common.Sizeof(partID):common.Sizeof(partID)+int(vidLength)],
+		key[common.Sizeof(partID)+int(vidLength):common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType)],
+		key[common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType):common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType)+common.Sizeof(rank)],
+		key[common.Sizeof(partID)+int(vidLength)+common.Sizeof(edgeType)+common.Sizeof(rank):n]
 
 	if err = common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	partID = partID | kEdge
 	partID >>= 8
 	if left, err = getVidString(l, p.opts.SpaceID, p.schema); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if right, err = getVidString(r, p.opts.SpaceID, p.schema); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if err = common.ConvertBytesToInt(&edgeType, &e, common.ByteOrder); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	//rank
 	if err = common.ConvertBytesToInt(&rank, &k, binary.BigEndian); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	rank ^= 1 << 63
-	if edgeType > 0 {
-		kvstring.Key = fmt.Sprintf("part:%d, src:%s, edge:%d, dst:%s, rank:%d", partID, left, edgeType, right, rank)
-	} else {
-		kvstring.Key = fmt.Sprintf("part:%d, src:%s, edge:%d, dst:%s, rank:%d", partID, right, edgeType, left, rank)
+	if edgeType < 0 {
+		left, right = right, left
 	}
-
-	rowData, err := decodeValue("edge", kv.Value, p.opts.SpaceID, int32(math.Abs(float64(edgeType))), p.schema)
-	if err != nil {
-		return nil, err
+	kvstring.Key = fmt.Sprintf("part:%d, src:%s, edge:%d, dst:%s, rank:%d", partID, left, edgeType, right, rank)
+	kvstring.AddField("part", partID)
+	kvstring.AddField("src", left)
+	kvstring.AddField("edge", edgeType)
+	kvstring.AddField("dst", right)
+	kvstring.AddField("rank", int64(rank))
+	switch int32(key[0]) {
+	case kPrime:
+		kvstring.Key += ", prime"
+		kvstring.AddField("state", "prime")
+	case kDoublePrime:
+		kvstring.Key += ", double prime"
+		kvstring.AddField("state", "double prime")
 	}
-	valuse := make([]string, 0)
-	valuse = append(valuse, fmt.Sprintf("version:%d", rowData.version))
-	row := rowData.dataset.Rows[0]
-
-	for i := 0; i < len(rowData.dataset.ColumnNames); i++ {
-		valuse = append(valuse, fmt.Sprintf("%s:%s", rowData.dataset.ColumnNames[i], formatValue(row.GetValues()[i])))
+	switch v := key[n]; v {
+	case edgeVersion:
+	case edgeLockVersion:
+		kvstring.Key += ", lock"
+		kvstring.AddField("state", "lock")
+	default:
+		kvstring.Key += fmt.Sprintf(", version:%d", v)
+		kvstring.AddField("edge_version", v)
 	}
-	valuse = append(valuse, fmt.Sprintf("timestamp:%d", rowData.timestamp))
-	kvstring.Value = strings.Join(valuse, ", ")
-
-	return kvstring, nil
+	// only the out edge is exported, the in edge is the same edge
+	if p.opts.Rows && edgeType > 0 && int32(key[0]) == kEdge && key[n] != edgeLockVersion {
+		if kvstring.Row, err = newEdgeRow(left, right, int64(rank), p.opts.SpaceID, p.schema); err != nil {
+			return nil, 0, err
+		}
+	}
+	return kvstring, edgeType, nil
 }
 
-func (p *edgeParser) Prefix() ([]*common.KV, error) {
+func (p *edgeParser) Iterate(fn func(*common.KV) error) error {
 	common.Logger.Debugf("Prefix edge key")
-	var (
-		// true is for positive edge
-		direct    bool = true
-		part      int32
-		left      string
-		right     string
-		rank      int64
-		vidLength int16
-	)
-	s := make([]byte, 0)
-
+	var part int32
 	if err := verifyOption(p.opts); err != nil {
-		return nil, err
+		return err
 	}
-	if p.opts.PartID == -1 && p.opts.Src == "" && p.opts.Dst == "" {
-		return nil, fmt.Errorf("must provide a valid part or a valid src/dst")
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
 	}
-	schema, err := schemacache.NewFileCache(p.opts.MetaAddres)
-	if err != nil {
-		return nil, err
+	if !p.opts.AllParts && p.opts.PartID == -1 && p.opts.Src == "" && p.opts.Dst == "" {
+		return fmt.Errorf("must provide a valid part or a valid src/dst")
 	}
-	if err := schema.Update(); err != nil {
-		return nil, err
+	schema, err := loadSchema(p.schema, p.opts)
+	if err != nil {
+		return err
 	}
 
 	p.schema = schema
 	space := schema.GetSpace(p.opts.SpaceID)
 	if space == nil {
-		return nil, fmt.Errorf("cannot find the space")
+		return fmt.Errorf("cannot find the space")
 	}
-	vidLength = space.GetProperties().GetVidType().GetTypeLength()
 
-	if p.opts.EdgeID != 0 {
-		if p.opts.EdgeID < 0 {
-			direct = false
+	if p.opts.Locks {
+		if p.opts.AllParts {
+			return prefixAllParts(space, p.opts.Limit, p.locks, fn)
 		}
-	} else {
-		if p.opts.Src == "" && p.opts.Dst != "" {
-			direct = false
+		if p.opts.PartID == -1 {
+			return fmt.Errorf("must provide a valid part to list the locks")
 		}
+		return p.locks(p.opts.PartID, p.opts.Limit, fn)
 	}
-
-	if direct {
-		left = p.opts.Src
-		right = p.opts.Dst
-	} else {
-		left = p.opts.Dst
-		right = p.opts.Src
+	if p.opts.AllParts {
+		return prefixAllParts(space, p.opts.Limit, p.iteratePart, fn)
 	}
 
+	_, left, _ := p.direction()
 	if left == "" {
 		part = p.opts.PartID
 	} else {
 		//get part by id
-		var bs []byte
-
 		bs, err := getVidByte(left, p.opts.SpaceID, p.schema)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		id, err := common.GetPartID(bs, space.GetProperties().GetPartitionNum())
 		if err != nil {
-			return nil, err
+			return err
 		}
 		part = id
 	}
+	return p.iteratePart(part, p.opts.Limit, fn)
+}
+
+// direction returns true for the out edges, and the vid in the key prefix and the vid at the key end.
+func (p *edgeParser) direction() (bool, string, string) {
+	// true is for positive edge
+	direct := true
+	if p.opts.EdgeID != 0 {
+		if p.opts.EdgeID < 0 {
+			direct = false
+		}
+	} else {
+		if p.opts.Src == "" && p.opts.Dst != "" {
+			direct = false
+		}
+	}
+	if direct {
+		return direct, p.opts.Src, p.opts.Dst
+	}
+	return direct, p.opts.Dst, p.opts.Src
+}
+
+// iteratePart passes at most limit edge kvs of the part to fn.
+func (p *edgeParser) iteratePart(part int32, limit int, fn func(*common.KV) error) error {
+	var rank int64
+	s := make([]byte, 0)
+	vidLength := p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().GetTypeLength()
+	direct, left, right := p.direction()
 
 	item := part<<8 | kEdge
 	var partData []byte
 	if err := common.ConvertIntToBytes(&item, &partData, common.ByteOrder); err != nil {
-		return nil, err
+		return err
 	}
 	s = append(s, partData...)
 	// append id
 	if left != "" {
-		vidBy, err := getVidByte(left, p.opts.SpaceID, schema)
+		vidBy, err := getVidByte(left, p.opts.SpaceID, p.schema)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, vidBy...)
 	}
 	// filter
 	if p.opts.EdgeID != 0 || right != "" {
-		fn := func(key []byte) bool {
+		cond := func(key []byte) bool {
 			// a extra byte
 			n := len(key) - 1
 			_, edgeKey, _, rightKey := key[common.Sizeof(part):common.Sizeof(part)+int(vidLength)],
@@ -206,12 +274,12 @@ func (p *edgeParser) Prefix() ([]*common.KV, error) {
 			}
 			return true
 		}
-		return p.engine.PrefixWithCondition(s, p.opts.Limit, fn, nil)
+		return p.engine.PrefixEach(s, limit, cond, nil, fn)
 	}
 
-	fn := func(key []byte) bool {
+	cond := func(key []byte) bool {
 		var edge int32
-		edgeKey := key[common.Sizeof(p.opts.PartID)+int(vidLength) : common.Sizeof(p.opts.PartID)+int(vidLength)+common.Sizeof(p.opts.PartID)]
+		edgeKey := key[common.Sizeof(part)+int(vidLength) : common.Sizeof(part)+int(vidLength)+common.Sizeof(part)]
 		if err := common.ConvertBytesToInt(&edge, &edgeKey, common.ByteOrder); err != nil {
 			panic(err)
 		}
@@ -226,5 +294,38 @@ func (p *edgeParser) Prefix() ([]*common.KV, error) {
 		return true
 	}
 
-	return p.engine.PrefixWithCondition(s, p.opts.Limit, fn, nil)
+	return p.engine.PrefixEach(s, limit, cond, nil, fn)
+}
+
+func (p *edgeParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
+}
+
+// locks passes at most limit dangling locks of the chained edges in the part to fn,
+// they are the lock keys in nebula 2.x, and the prime keys in nebula 3.x.
+func (p *edgeParser) locks(part int32, limit int, fn func(*common.KV) error) error {
+	count := 0
+	counted := func(kv *common.KV) error {
+		count++
+		return fn(kv)
+	}
+	for _, t := range []int32{kEdge, kPrime, kDoublePrime} {
+		var (
+			prefix []byte
+			cond   func([]byte) bool
+		)
+		item := part<<8 | t
+		if err := common.ConvertIntToBytes(&item, &prefix, common.ByteOrder); err != nil {
+			return err
+		}
+		if t == kEdge {
+			cond = func(key []byte) bool {
+				return key[len(key)-1] == edgeLockVersion
+			}
+		}
+		if err := p.engine.PrefixEach(prefix, limit-count, cond, nil, counted); err != nil {
+			return err
+		}
+	}
+	return nil
 }
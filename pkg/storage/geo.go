@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+)
+
+// wkb shape types, follow nebula GeoShape
+const (
+	wkbPoint      uint32 = 1
+	wkbLineString uint32 = 2
+	wkbPolygon    uint32 = 3
+)
+
+// wkbReader decodes the WKB payload which nebula keeps in the string heap.
+// byteOrder(1byte) + shape(4byte) + coordinates
+type wkbReader struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func parseWKB(b []byte) (*nebula.Geography, error) {
+	if len(b) < 1+4 {
+		return nil, fmt.Errorf("invalid wkb, length is %d", len(b))
+	}
+	r := &wkbReader{buf: b}
+	switch b[0] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid wkb byte order %d", b[0])
+	}
+	r.pos = 1
+	shape, err := r.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	g := nebula.NewGeography()
+	switch shape {
+	case wkbPoint:
+		coord, err := r.readCoordinate()
+		if err != nil {
+			return nil, err
+		}
+		g.PtVal = &nebula.Point{Coord: coord}
+
+	case wkbLineString:
+		coords, err := r.readCoordinates()
+		if err != nil {
+			return nil, err
+		}
+		g.LsVal = &nebula.LineString{CoordList: coords}
+
+	case wkbPolygon:
+		n, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		rings := make([][]*nebula.Coordinate, 0, n)
+		for i := uint32(0); i < n; i++ {
+			coords, err := r.readCoordinates()
+			if err != nil {
+				return nil, err
+			}
+			rings = append(rings, coords)
+		}
+		g.PgVal = &nebula.Polygon{CoordListList: rings}
+
+	default:
+		return nil, fmt.Errorf("not support this shape: %d", shape)
+	}
+	return g, nil
+}
+
+func (r *wkbReader) readUint32() (uint32, error) {
+	if r.pos+4 > len(r.buf) {
+		return 0, fmt.Errorf("wkb is truncated at %d", r.pos)
+	}
+	b := r.buf[r.pos : r.pos+4]
+	r.pos += 4
+	var v uint32
+	if err := common.ConvertBytesToInt(&v, &b, r.order); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func (r *wkbReader) readDouble() (float64, error) {
+	if r.pos+8 > len(r.buf) {
+		return 0, fmt.Errorf("wkb is truncated at %d", r.pos)
+	}
+	b := r.buf[r.pos : r.pos+8]
+	r.pos += 8
+	var v uint64
+	if err := common.ConvertBytesToInt(&v, &b, r.order); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(v), nil
+}
+
+func (r *wkbReader) readCoordinate() (*nebula.Coordinate, error) {
+	x, err := r.readDouble()
+	if err != nil {
+		return nil, err
+	}
+	y, err := r.readDouble()
+	if err != nil {
+		return nil, err
+	}
+	return &nebula.Coordinate{X: x, Y: y}, nil
+}
+
+func (r *wkbReader) readCoordinates() ([]*nebula.Coordinate, error) {
+	n, err := r.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	coords := make([]*nebula.Coordinate, 0, n)
+	for i := uint32(0); i < n; i++ {
+		c, err := r.readCoordinate()
+		if err != nil {
+			return nil, err
+		}
+		coords = append(coords, c)
+	}
+	return coords, nil
+}
+
+// copy from nebula-go
+func toWKT(geo *nebula.Geography) string {
+	if geo == nil {
+		return ""
+	}
+	if geo.IsSetPtVal() {
+		ptVal := geo.GetPtVal()
+		coord := ptVal.GetCoord()
+		return fmt.Sprintf("POINT(%v %v)", coord.GetX(), coord.GetY())
+	} else if geo.IsSetLsVal() {
+		lsVal := geo.GetLsVal()
+		coordList := lsVal.GetCoordList()
+		wkt := "LINESTRING("
+		for i, coord := range coordList {
+			wkt += fmt.Sprintf("%v %v", coord.GetX(), coord.GetY())
+			if i != len(coordList)-1 {
+				wkt += ", "
+			}
+		}
+		wkt += ")"
+		return wkt
+	} else if geo.IsSetPgVal() {
+		pgVal := geo.GetPgVal()
+		coordListList := pgVal.GetCoordListList()
+		wkt := "POLYGON("
+		for i, coordList := range coordListList {
+			wkt += "("
+			for j, coord := range coordList {
+				wkt += fmt.Sprintf("%v %v", coord.GetX(), coord.GetY())
+				if j != len(coordList)-1 {
+					wkt += ", "
+				}
+			}
+			wkt += ")"
+			if i != len(coordListList)-1 {
+				wkt += ", "
+			}
+		}
+		wkt += ")"
+
+		return wkt
+	}
+	return ""
+}
+
+// s2 cell id
+// face(3bit) + hilbert position(2bit per level) + 1 + 0...
+const (
+	s2MaxLevel   = 30
+	s2PosBits    = 2*s2MaxLevel + 1
+	s2SwapMask   = 0x01
+	s2InvertMask = 0x02
+)
+
+var (
+	s2PosToIJ = [4][4]int{
+		{0, 1, 3, 2}, // canonical order
+		{0, 2, 3, 1}, // axes swapped
+		{3, 2, 0, 1}, // bits inverted
+		{3, 1, 0, 2}, // swapped & inverted
+	}
+	s2PosToOrientation = [4]int{s2SwapMask, 0, 0, s2InvertMask | s2SwapMask}
+)
+
+type s2Cell struct {
+	id    uint64
+	face  int
+	level int
+	i     uint32
+	j     uint32
+}
+
+func newS2Cell(id uint64) (*s2Cell, error) {
+	face := int(id >> s2PosBits)
+	lsb := id & -id
+	if face > 5 || lsb&0x1555555555555555 == 0 {
+		return nil, fmt.Errorf("invalid s2 cell id %d", id)
+	}
+	c := &s2Cell{
+		id:    id,
+		face:  face,
+		level: s2MaxLevel - bits.TrailingZeros64(id)>>1,
+	}
+	orientation := face & s2SwapMask
+	for k := 1; k <= s2MaxLevel; k++ {
+		pos := int(id>>uint(s2PosBits-2*k)) & 0x03
+		ij := s2PosToIJ[orientation][pos]
+		c.i = c.i<<1 | uint32(ij>>1)
+		c.j = c.j<<1 | uint32(ij&0x01)
+		orientation ^= s2PosToOrientation[pos]
+	}
+	return c, nil
+}
+
+// bound returns the lat/lng range of the cell vertices in degrees,
+// the edges of a cell bulge a little beyond the vertices, the range is not padded.
+// the lng range wraps when the cell crosses the antimeridian, e.g. [170, -170],
+// and it's [-180, 180] when the cell contains a pole.
+func (c *s2Cell) bound() (latLo, latHi, lngLo, lngHi float64) {
+	size := uint32(1) << uint(s2MaxLevel-c.level)
+	i0, j0 := c.i&^(size-1), c.j&^(size-1)
+	latLo, latHi = math.Inf(1), math.Inf(-1)
+	lngs := make([]float64, 0, 4)
+	for _, d := range [][2]uint32{{0, 0}, {size, 0}, {0, size}, {size, size}} {
+		lat, lng := s2FaceIJToLatLng(c.face, uint64(i0+d[0]), uint64(j0+d[1]))
+		latLo, latHi = math.Min(latLo, lat), math.Max(latHi, lat)
+		lngs = append(lngs, lng)
+	}
+	// the poles are the centers of face 2 and 5
+	center := uint32(1) << (s2MaxLevel - 1)
+	if (c.face == 2 || c.face == 5) && i0 <= center && center <= i0+size && j0 <= center && center <= j0+size {
+		if c.face == 2 {
+			latHi = 90
+		} else {
+			latLo = -90
+		}
+		return latLo, latHi, -180, 180
+	}
+	// the range is the circle without the largest gap between the vertices
+	sort.Float64s(lngs)
+	gap := lngs[0] + 360 - lngs[len(lngs)-1]
+	lngLo, lngHi = lngs[0], lngs[len(lngs)-1]
+	for k := 1; k < len(lngs); k++ {
+		if d := lngs[k] - lngs[k-1]; d > gap {
+			gap = d
+			lngLo, lngHi = lngs[k], lngs[k-1]
+		}
+	}
+	return
+}
+
+func s2STToUV(s float64) float64 {
+	if s >= 0.5 {
+		return (1.0 / 3.0) * (4*s*s - 1)
+	}
+	return (1.0 / 3.0) * (1 - 4*(1-s)*(1-s))
+}
+
+func s2FaceIJToLatLng(face int, i, j uint64) (float64, float64) {
+	u := s2STToUV(float64(i) / float64(uint64(1)<<s2MaxLevel))
+	v := s2STToUV(float64(j) / float64(uint64(1)<<s2MaxLevel))
+	var x, y, z float64
+	switch face {
+	case 0:
+		x, y, z = 1, u, v
+	case 1:
+		x, y, z = -u, 1, v
+	case 2:
+		x, y, z = -u, -v, 1
+	case 3:
+		x, y, z = -1, -v, -u
+	case 4:
+		x, y, z = v, -1, -u
+	default:
+		x, y, z = v, u, -1
+	}
+	lat := math.Atan2(z, math.Sqrt(x*x+y*y)) * 180 / math.Pi
+	lng := math.Atan2(y, x) * 180 / math.Pi
+	return lat, lng
+}
+
+// getGeoIndexValue decodes the s2 cell id of a geo index key into a map value.
+// cell id is encoded as a big endian uint64.
+func getGeoIndexValue(b []byte) (*nebula.Value, error) {
+	var id uint64
+	if err := common.ConvertBytesToInt(&id, &b, binary.BigEndian); err != nil {
+		return nil, err
+	}
+	cell, err := newS2Cell(id)
+	if err != nil {
+		return nil, err
+	}
+	latLo, latHi, lngLo, lngHi := cell.bound()
+	level := int64(cell.level)
+	m := nebula.NewNMap()
+	m.Kvs = map[string]*nebula.Value{
+		"cell":  nebula.NewValue().SetSVal([]byte(strconv.FormatUint(id, 10))),
+		"level": nebula.NewValue().SetIVal(&level),
+		"lat":   newFloatListValue(latLo, latHi),
+		"lng":   newFloatListValue(lngLo, lngHi),
+	}
+	return nebula.NewValue().SetMVal(m), nil
+}
+
+func newFloatListValue(fs ...float64) *nebula.Value {
+	l := nebula.NewNList()
+	for i := range fs {
+		f := fs[i]
+		l.Values = append(l.Values, nebula.NewValue().SetFVal(&f))
+	}
+	return nebula.NewValue().SetLVal(l)
+}
+
+// writeWKB encodes the geography in little endian WKB, it's the reverse of parseWKB.
+func writeWKB(g *nebula.Geography) ([]byte, error) {
+	w := &wkbWriter{buf: []byte{1}}
+	switch {
+	case g.IsSetPtVal():
+		w.writeUint32(wkbPoint)
+		w.writeCoordinate(g.GetPtVal().GetCoord())
+	case g.IsSetLsVal():
+		w.writeUint32(wkbLineString)
+		w.writeCoordinates(g.GetLsVal().GetCoordList())
+	case g.IsSetPgVal():
+		w.writeUint32(wkbPolygon)
+		rings := g.GetPgVal().GetCoordListList()
+		w.writeUint32(uint32(len(rings)))
+		for _, coords := range rings {
+			w.writeCoordinates(coords)
+		}
+	default:
+		return nil, fmt.Errorf("empty geography")
+	}
+	return w.buf, nil
+}
+
+type wkbWriter struct {
+	buf []byte
+}
+
+func (w *wkbWriter) writeUint32(v uint32) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	w.buf = append(w.buf, b...)
+}
+
+func (w *wkbWriter) writeCoordinate(c *nebula.Coordinate) {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b, math.Float64bits(c.GetX()))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(c.GetY()))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *wkbWriter) writeCoordinates(coords []*nebula.Coordinate) {
+	w.writeUint32(uint32(len(coords)))
+	for _, c := range coords {
+		w.writeCoordinate(c)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"strings"
 
@@ -13,7 +14,8 @@ import (
 )
 
 // indexParser
-// key: (type + part) + src + edge type(4bit) + dst
+// tag index key: (type + part) + index id(4bit) + index values + nullable bitmap(2bit, optional) + vid
+// edge index key: (type + part) + index id(4bit) + index values + nullable bitmap(2bit, optional) + src + rank(8bit) + dst
 // value: []
 type indexParser struct {
 	opts    *pkg.Option
@@ -22,6 +24,8 @@ type indexParser struct {
 	schema  schemacache.Schemacache
 	index   *meta.IndexItem
 	hasNull bool
+	// the index is on an edge
+	isEdge bool
 }
 
 type indexValues struct {
@@ -44,23 +48,29 @@ func (p *indexParser) Parse(kv *common.KV) (*common.KVString, error) {
 		kvstring = &common.KVString{}
 		partID   int32
 		indexID  int32
-		vid      string
+		rank     uint64
 		err      error
 	)
-	vidLength := p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().TypeLength
+	vidLength := int(p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().TypeLength)
 	n := len(kv.Key)
-	pt, i, v, id := kv.Key[:common.Sizeof(partID)],
+	tail := p.tailLength(vidLength)
+	nullLength := 0
+	if p.hasNull {
+		nullLength = 2
+	}
+	if n < common.Sizeof(partID)+common.Sizeof(indexID)+nullLength+tail {
+		return nil, fmt.Errorf("invalid index key, length is %d", n)
+	}
+	pt, i, v, nullableBit, id := kv.Key[:common.Sizeof(partID)],
 		kv.Key[common.Sizeof(partID):common.Sizeof(partID)+common.Sizeof(indexID)],
-		kv.Key[common.Sizeof(partID)+common.Sizeof(indexID):n-int(vidLength)],
-		kv.Key[n-int(vidLength):]
+		kv.Key[common.Sizeof(partID)+common.Sizeof(indexID):n-tail-nullLength],
+		kv.Key[n-tail-nullLength:n-tail],
+		kv.Key[n-tail:]
 	if err = common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
 		return nil, err
 	}
 	partID = partID | kIndex
 	partID >>= 8
-	if vid, err = getVidString(id, p.opts.SpaceID, p.schema); err != nil {
-		return nil, err
-	}
 	if err = common.ConvertBytesToInt(&indexID, &i, common.ByteOrder); err != nil {
 		return nil, err
 	}
@@ -69,127 +79,193 @@ func (p *indexParser) Parse(kv *common.KV) (*common.KVString, error) {
 	if err != nil {
 		return nil, err
 	}
+	if int(iv.pos) != len(v) {
+		return nil, fmt.Errorf("invalid index key, length of index values is %d, expect is %d", len(v), iv.pos)
+	}
 	var values []string
-	var nullableBit = kv.Key[n-int(vidLength)-2 : n-int(vidLength)]
+	kvstring.AddField("part", partID)
+	kvstring.AddField("index", indexID)
 	var nbit uint16
-	if err := common.ConvertBytesToInt(&nbit, &nullableBit, common.ByteOrder); err != nil {
-		return nil, err
+	if p.hasNull {
+		if err := common.ConvertBytesToInt(&nbit, &nullableBit, common.ByteOrder); err != nil {
+			return nil, err
+		}
 	}
 	for i, f := range p.index.GetFields() {
 		if nbit&(0x8000>>i) == 0x8000>>i {
 			values = append(values, fmt.Sprintf("%s:%s", f.GetName(), "__null__"))
+			addField(kvstring, string(f.GetName()), nil)
 		} else {
-			values = append(values, fmt.Sprintf("%s:%s", f.GetName(), formatValue(iv.values[i])))
+			values = append(values, fmt.Sprintf("%s:%s", f.GetName(), FormatValue(iv.values[i])))
+			addField(kvstring, string(f.GetName()), fieldValue(iv.values[i]))
 		}
 	}
 
-	kvstring.Key = fmt.Sprintf("part:%d, index:%d, %s, vid:%s",
-		partID, indexID, strings.Join(values, ","), vid)
+	if !p.isEdge {
+		vid, err := getVidString(id, p.opts.SpaceID, p.schema)
+		if err != nil {
+			return nil, err
+		}
+		kvstring.Key = fmt.Sprintf("part:%d, index:%d, %s, vid:%s",
+			partID, indexID, strings.Join(values, ","), vid)
+		addField(kvstring, "vid", vid)
+		return kvstring, nil
+	}
+
+	l, k, r := id[:vidLength], id[vidLength:vidLength+common.Sizeof(rank)], id[vidLength+common.Sizeof(rank):]
+	src, err := getVidString(l, p.opts.SpaceID, p.schema)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := getVidString(r, p.opts.SpaceID, p.schema)
+	if err != nil {
+		return nil, err
+	}
+	if err = common.ConvertBytesToInt(&rank, &k, binary.BigEndian); err != nil {
+		return nil, err
+	}
+	rank ^= 1 << 63
+	kvstring.Key = fmt.Sprintf("part:%d, index:%d, %s, src:%s, rank:%d, dst:%s",
+		partID, indexID, strings.Join(values, ","), src, int64(rank), dst)
+	addField(kvstring, "src", src)
+	addField(kvstring, "rank", int64(rank))
+	addField(kvstring, "dst", dst)
 
 	return kvstring, nil
 }
 
-func (p *indexParser) Prefix() ([]*common.KV, error) {
-	s := make([]byte, 0)
-	var (
-		part    int32
-		vidBs   []byte
-		indexBs []byte
-	)
+// setIndex sets the index, and whether the key has an edge tail or a nullable bitmap.
+func (p *indexParser) setIndex(index *meta.IndexItem) {
+	p.index = index
+	p.isEdge = index.GetSchemaID().IsSetEdgeType()
+	p.hasNull = false
+	for _, f := range index.GetFields() {
+		if f.GetNullable() {
+			p.hasNull = true
+			break
+		}
+	}
+}
+
+// tailLength is the length of vid for tag index, or src + rank + dst for edge index.
+func (p *indexParser) tailLength(vidLength int) int {
+	if p.isEdge {
+		return vidLength + 8 + vidLength
+	}
+	return vidLength
+}
+
+func (p *indexParser) Iterate(fn func(*common.KV) error) error {
+	var part int32
 
 	if err := verifyOption(p.opts); err != nil {
-		return nil, err
+		return err
+	}
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
 	}
 	if p.opts.IndexID == -1 {
-		return nil, fmt.Errorf("must provide a valid index id")
+		return fmt.Errorf("must provide a valid index id")
 	}
-	if p.opts.PartID == -1 && p.opts.VID == "" {
-		return nil, fmt.Errorf("must provide a valid part or vid")
+	if !p.opts.AllParts && p.opts.PartID == -1 && p.opts.VID == "" && p.opts.Src == "" {
+		return fmt.Errorf("must provide a valid part, vid or src")
 	}
-	schema, err := schemacache.NewFileCache(p.opts.MetaAddres)
+	schema, err := loadSchema(p.schema, p.opts)
 	if err != nil {
-		return nil, err
-	}
-	if err := schema.Update(); err != nil {
-		return nil, err
+		return err
 	}
 
 	p.schema = schema
 	space := schema.GetSpace(p.opts.SpaceID)
 	if space == nil {
-		return nil, fmt.Errorf("cannot find the space")
+		return fmt.Errorf("cannot find the space")
 	}
 	indexes := schema.GetIndexes(p.opts.SpaceID)
 	for _, i := range indexes {
 		if i.GetIndexID() == p.opts.IndexID {
-			p.index = i
+			p.setIndex(i)
 			break
 		}
 	}
 	if p.index == nil {
-		return nil, fmt.Errorf("not a valid index id")
+		return fmt.Errorf("not a valid index id")
 	}
-
-	for _, f := range p.index.GetFields() {
-		if f.GetNullable() && p.hasNull {
-			p.hasNull = true
-		}
+	if p.isEdge && p.opts.VID != "" {
+		return fmt.Errorf("index %d is an edge index, use src or dst", p.opts.IndexID)
+	}
+	if !p.isEdge && (p.opts.Src != "" || p.opts.Dst != "") {
+		return fmt.Errorf("index %d is a tag index, use vid", p.opts.IndexID)
+	}
+	if p.opts.AllParts {
+		return prefixAllParts(space, p.opts.Limit, p.iteratePart, fn)
+	}
+	if p.isEdge && p.opts.PartID == -1 && p.opts.Src == "" {
+		return fmt.Errorf("must provide a valid part or src for an edge index")
 	}
 
-	if p.opts.VID == "" {
+	// edge index is in the part of src
+	if p.opts.Src != "" {
+		srcBs, err := getVidByte(p.opts.Src, p.opts.SpaceID, schema)
+		if err != nil {
+			return err
+		}
+		id, err := common.GetPartID(srcBs, space.GetProperties().GetPartitionNum())
+		if err != nil {
+			return err
+		}
+		part = id
+	} else if p.opts.VID == "" {
 		part = p.opts.PartID
 	} else {
-		vidBs, err = getVidByte(p.opts.VID, p.opts.SpaceID, schema)
+		vidBs, err := getVidByte(p.opts.VID, p.opts.SpaceID, schema)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		id, err := common.GetPartID(vidBs, space.GetProperties().GetPartitionNum())
 		if err != nil {
-			return nil, err
+			return err
 		}
 		part = id
 	}
+	return p.iteratePart(part, p.opts.Limit, fn)
+}
+
+// iteratePart passes at most limit index kvs of the part to fn.
+func (p *indexParser) iteratePart(part int32, limit int, fn func(*common.KV) error) error {
+	s := make([]byte, 0)
+	var indexBs []byte
 
 	item := part<<8 | kIndex
 	var partData []byte
 	if err := common.ConvertIntToBytes(&item, &partData, common.ByteOrder); err != nil {
-		return nil, err
+		return err
 	}
 	s = append(s, partData...)
 	//index id
 	if err := common.ConvertIntToBytes(&p.opts.IndexID, &indexBs, common.ByteOrder); err != nil {
-		return nil, err
+		return err
 	}
 	s = append(s, indexBs...)
 
 	if p.opts.VID != "" {
+		vidBs, err := getVidByte(p.opts.VID, p.opts.SpaceID, p.schema)
+		if err != nil {
+			return err
+		}
 		var length int
-		var hasNull bool = false
 		for _, f := range p.index.GetFields() {
-			if f.GetNullable() && !hasNull {
-				hasNull = true
-			}
-			switch f.GetType().GetType() {
-			case nebula.PropertyType_GEOGRAPHY:
-				return nil, fmt.Errorf("not implement the type %v", f.GetType())
-
-			case nebula.PropertyType_FIXED_STRING:
-				length += int(f.GetType().GetTypeLength())
-
-			default:
-				l, err := getIndexTypeLength(f.GetType().GetType())
-				if err != nil {
-					return nil, err
-				}
-				length += l
+			l, err := getIndexFieldLength(f)
+			if err != nil {
+				return err
 			}
+			length += l
 		}
 		//int16 for nullable bitmap
-		if hasNull {
+		if p.hasNull {
 			length += 2
 		}
-		fn := func(key []byte) bool {
-			vid := key[common.Sizeof(p.opts.PartID)+common.Sizeof(p.opts.IndexID)+length:]
+		cond := func(key []byte) bool {
+			vid := key[common.Sizeof(part)+common.Sizeof(p.opts.IndexID)+length:]
 			expectVid := make([]byte, len(vid))
 			copy(expectVid, vidBs)
 			if bytes.Compare(vid, expectVid) != 0 {
@@ -200,10 +276,64 @@ func (p *indexParser) Prefix() ([]*common.KV, error) {
 			}
 		}
 
-		return p.engine.PrefixWithCondition(s, p.opts.Limit, fn, nil)
+		return p.engine.PrefixEach(s, limit, cond, nil, fn)
 
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	if p.isEdge && (p.opts.Src != "" || p.opts.Dst != "") {
+		cond, err := p.edgeCondition()
+		if err != nil {
+			return err
+		}
+		return p.engine.PrefixEach(s, limit, cond, nil, fn)
+	}
+	return p.engine.PrefixEach(s, limit, nil, nil, fn)
+}
+
+func (p *indexParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
+}
+
+// edgeCondition filters the edge index keys by src and dst, which are at the end of the key.
+func (p *indexParser) edgeCondition() (func([]byte) bool, error) {
+	vidLength := int(p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().GetTypeLength())
+	tail := p.tailLength(vidLength)
+	expect := func(vid string) ([]byte, error) {
+		if vid == "" {
+			return nil, nil
+		}
+		bs, err := getVidByte(vid, p.opts.SpaceID, p.schema)
+		if err != nil {
+			return nil, err
+		}
+		// string vid is padded in the key
+		e := make([]byte, vidLength)
+		copy(e, bs)
+		return e, nil
+	}
+	srcBs, err := expect(p.opts.Src)
+	if err != nil {
+		return nil, err
+	}
+	dstBs, err := expect(p.opts.Dst)
+	if err != nil {
+		return nil, err
+	}
+	return func(key []byte) bool {
+		n := len(key)
+		if n < tail {
+			return false
+		}
+		src, dst := key[n-tail:n-tail+vidLength], key[n-vidLength:]
+		if srcBs != nil && !bytes.Equal(src, srcBs) {
+			common.Logger.Debugf("ignore the key, expect src is %v, actual is %v, the key is %v", srcBs, src, key)
+			return false
+		}
+		if dstBs != nil && !bytes.Equal(dst, dstBs) {
+			common.Logger.Debugf("ignore the key, expect dst is %v, actual is %v, the key is %v", dstBs, dst, key)
+			return false
+		}
+		return true
+	}, nil
 }
 
 func newIndexValues(buf []byte, index *meta.IndexItem) (*indexValues, error) {
@@ -211,15 +341,13 @@ func newIndexValues(buf []byte, index *meta.IndexItem) (*indexValues, error) {
 	copy(newBuf, buf)
 	vs := &indexValues{buf: newBuf, index: index}
 	for _, f := range vs.index.GetFields() {
-		var l int16
-		if f.GetType().GetType() == nebula.PropertyType_FIXED_STRING {
-			l = f.GetType().GetTypeLength()
-		} else {
-			t, err := getIndexTypeLength(f.GetType().GetType())
-			if err != nil {
-				return nil, err
-			}
-			l = int16(t)
+		t, err := getIndexFieldLength(f)
+		if err != nil {
+			return nil, err
+		}
+		l := int16(t)
+		if int(vs.pos+l) > len(vs.buf) {
+			return nil, fmt.Errorf("index values are truncated, field is %s", f.GetName())
 		}
 		b := vs.buf[vs.pos : vs.pos+l]
 		vs.pos += l
@@ -232,3 +360,27 @@ func newIndexValues(buf []byte, index *meta.IndexItem) (*indexValues, error) {
 	}
 	return vs, nil
 }
+
+func getIndexItem(schema schemacache.Schemacache, spaceID, indexID int32) *meta.IndexItem {
+	for _, i := range schema.GetIndexes(spaceID) {
+		if i.GetIndexID() == indexID {
+			return i
+		}
+	}
+	return nil
+}
+
+// getIndexFieldLength returns the length of the field in the index key,
+// strings are padded to the declared length of the index.
+func getIndexFieldLength(f *meta.ColumnDef) (int, error) {
+	switch f.GetType().GetType() {
+	case nebula.PropertyType_FIXED_STRING, nebula.PropertyType_STRING:
+		l := int(f.GetType().GetTypeLength())
+		if l <= 0 {
+			return 0, fmt.Errorf("the length of string field %s is not declared in the index", f.GetName())
+		}
+		return l, nil
+	default:
+		return getIndexTypeLength(f.GetType().GetType())
+	}
+}
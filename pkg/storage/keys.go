@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+)
+
+// EncodeVid returns the vid padded to the vid length of the space, and the part of the vid.
+// the part is hashed from the vid without the padding as nebula.
+func EncodeVid(vid string, spaceID int32, schema schemacache.Schemacache) ([]byte, int32, error) {
+	b, err := getVidByte(vid, spaceID, schema)
+	if err != nil {
+		return nil, 0, err
+	}
+	props := schema.GetSpace(spaceID).GetProperties()
+	part, err := common.GetPartID(b, props.GetPartitionNum())
+	if err != nil {
+		return nil, 0, err
+	}
+	if l := int(props.GetVidType().GetTypeLength()); len(b) < l {
+		b = append(b, make([]byte, l-len(b))...)
+	}
+	return b, part, nil
+}
+
+// TagKey encodes the tag key, (type + part) + vid + tag id(4byte)
+func TagKey(part int32, vid []byte, tagID int32) []byte {
+	key := make([]byte, 4, 4+len(vid)+4)
+	common.ByteOrder.PutUint32(key, uint32(part<<8|kTag))
+	key = append(key, vid...)
+	return append(key, int32Bytes(tagID)...)
+}
+
+// EdgeKey encodes the edge key, (type + part) + src + edge type(4byte) + rank(8byte) + dst + edge version,
+// the in edge has the negative edge type, and the part of dst.
+func EdgeKey(part int32, src []byte, edgeType int32, rank int64, dst []byte) []byte {
+	key := make([]byte, 4, 4+len(src)+4+8+len(dst)+1)
+	common.ByteOrder.PutUint32(key, uint32(part<<8|kEdge))
+	key = append(key, src...)
+	key = append(key, int32Bytes(edgeType)...)
+	// rank is big endian with the sign bit flipped, so the keys are sorted by rank
+	r := make([]byte, 8)
+	binary.BigEndian.PutUint64(r, uint64(rank)^(1<<63))
+	key = append(key, r...)
+	key = append(key, dst...)
+	return append(key, edgeVersion)
+}
+
+func int32Bytes(i int32) []byte {
+	b := make([]byte, 4)
+	common.ByteOrder.PutUint32(b, uint32(i))
+	return b
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// kvParser
+// the data written by the raw kv interface.
+// key: (type + part) + key
+// value: value
+type kvParser struct {
+	opts   *pkg.Option
+	engine *common.Engine
+}
+
+func (p *kvParser) New(engine *common.Engine, opts *pkg.Option) pkg.Parser {
+	return &kvParser{
+		opts:   opts,
+		engine: engine,
+	}
+}
+
+func (p *kvParser) Parse(kv *common.KV) (*common.KVString, error) {
+	var (
+		kvstring = &common.KVString{}
+		partID   int32
+	)
+	if len(kv.Key) < common.Sizeof(partID) {
+		return nil, fmt.Errorf("invalid kv key, length is %d", len(kv.Key))
+	}
+	pt, k := kv.Key[:common.Sizeof(partID)], kv.Key[common.Sizeof(partID):]
+	if err := common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	partID >>= 8
+	key, err := encodeBytes(k, p.opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	value, err := encodeBytes(kv.Value, p.opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	kvstring.Key = fmt.Sprintf("part:%d, key:%s", partID, key)
+	kvstring.Value = value
+	kvstring.AddField("part", partID)
+	kvstring.AddField("key", key)
+	kvstring.AddField("value", value)
+	return kvstring, nil
+}
+
+func encodeBytes(b []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", pkg.EncodingText:
+		return string(b), nil
+	case pkg.EncodingHex:
+		return hex.EncodeToString(b), nil
+	case pkg.EncodingBase64:
+		return base64.StdEncoding.EncodeToString(b), nil
+	default:
+		return "", fmt.Errorf("invalid encoding %s", encoding)
+	}
+}
+
+func (p *kvParser) Iterate(fn func(*common.KV) error) error {
+	if _, err := encodeBytes(nil, p.opts.Encoding); err != nil {
+		return err
+	}
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
+	}
+	// the first byte of the key is the type, it's the prefix of all parts
+	if p.opts.AllParts {
+		return p.engine.PrefixEach([]byte{byte(kKeyValue)}, p.opts.Limit, nil, nil, fn)
+	}
+	if p.opts.PartID == -1 {
+		return fmt.Errorf("must provide a valid part")
+	}
+	item := p.opts.PartID<<8 | kKeyValue
+	var s []byte
+	if err := common.ConvertIntToBytes(&item, &s, common.ByteOrder); err != nil {
+		return err
+	}
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *kvParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+)
+
+// operation types of the index rebuilding
+const (
+	operationModify uint32 = 0x01
+	operationDelete uint32 = 0x02
+)
+
+// operationParser
+// the operation logs written by storaged when the index is rebuilding.
+// key: (type + part) + timestamp(8bit) + operation type(4bit) + index key
+// value: [] or the index value
+type operationParser struct {
+	opts   *pkg.Option
+	engine *common.Engine
+	schema schemacache.Schemacache
+}
+
+func (p *operationParser) New(engine *common.Engine, opts *pkg.Option) pkg.Parser {
+	return &operationParser{
+		opts:   opts,
+		engine: engine,
+	}
+}
+
+func (p *operationParser) Parse(kv *common.KV) (*common.KVString, error) {
+	var (
+		kvstring  = &common.KVString{}
+		partID    int32
+		timestamp int64
+		opType    uint32
+		indexID   int32
+		operation string
+	)
+	headerLength := common.Sizeof(partID) + common.Sizeof(timestamp) + common.Sizeof(opType)
+	if len(kv.Key) < headerLength+common.Sizeof(partID)+common.Sizeof(indexID) {
+		return nil, fmt.Errorf("invalid operation key, length is %d", len(kv.Key))
+	}
+	pt, ts, o, indexKey := kv.Key[:common.Sizeof(partID)],
+		kv.Key[common.Sizeof(partID):common.Sizeof(partID)+common.Sizeof(timestamp)],
+		kv.Key[common.Sizeof(partID)+common.Sizeof(timestamp):headerLength],
+		kv.Key[headerLength:]
+	if err := common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	partID >>= 8
+	if err := common.ConvertBytesToInt(&timestamp, &ts, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&opType, &o, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	switch opType {
+	case operationModify:
+		operation = "modify"
+	case operationDelete:
+		operation = "delete"
+	default:
+		return nil, fmt.Errorf("unknown operation type %d", opType)
+	}
+
+	// the index key is a complete index key
+	i := indexKey[common.Sizeof(partID) : common.Sizeof(partID)+common.Sizeof(indexID)]
+	if err := common.ConvertBytesToInt(&indexID, &i, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	index := getIndexItem(p.schema, p.opts.SpaceID, indexID)
+	if index == nil {
+		return nil, fmt.Errorf("cannot find the index %d", indexID)
+	}
+	ip := &indexParser{opts: p.opts, schema: p.schema}
+	ip.setIndex(index)
+	indexString, err := ip.Parse(common.NewKV(indexKey, kv.Value))
+	if err != nil {
+		return nil, err
+	}
+	kvstring.Key = fmt.Sprintf("part:%d, operation:%s, timestamp:%d, %s", partID, operation, timestamp, indexString.Key)
+	kvstring.AddField("part", partID)
+	kvstring.AddField("operation", operation)
+	kvstring.AddField("timestamp", timestamp)
+	for _, f := range indexString.Fields {
+		if f.Name != "part" {
+			addField(kvstring, f.Name, f.Value)
+		}
+	}
+	return kvstring, nil
+}
+
+func (p *operationParser) Iterate(fn func(*common.KV) error) error {
+	var part int32
+	if err := verifyOption(p.opts); err != nil {
+		return err
+	}
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
+	}
+	if !p.opts.AllParts && p.opts.PartID == -1 && p.opts.VID == "" {
+		return fmt.Errorf("must provide a valid part or a valid VID")
+	}
+	schema, err := NewSchemaCache(p.opts)
+	if err != nil {
+		return err
+	}
+	p.schema = schema
+	space := schema.GetSpace(p.opts.SpaceID)
+	if space == nil {
+		return fmt.Errorf("cannot find the space")
+	}
+	if p.opts.AllParts {
+		return prefixAllParts(space, p.opts.Limit, p.iteratePart, fn)
+	}
+
+	if p.opts.VID == "" {
+		part = p.opts.PartID
+	} else {
+		bs, err := getVidByte(p.opts.VID, p.opts.SpaceID, p.schema)
+		if err != nil {
+			return err
+		}
+		id, err := common.GetPartID(bs, space.GetProperties().GetPartitionNum())
+		if err != nil {
+			return err
+		}
+		part = id
+	}
+	return p.iteratePart(part, p.opts.Limit, fn)
+}
+
+// iteratePart passes at most limit operation kvs of the part to fn.
+func (p *operationParser) iteratePart(part int32, limit int, fn func(*common.KV) error) error {
+	item := part<<8 | kOperation
+	var s []byte
+	if err := common.ConvertIntToBytes(&item, &s, common.ByteOrder); err != nil {
+		return err
+	}
+	if p.opts.IndexID == -1 {
+		return p.engine.PrefixEach(s, limit, nil, nil, fn)
+	}
+	// only the operations of the index
+	var indexBs []byte
+	if err := common.ConvertIntToBytes(&p.opts.IndexID, &indexBs, common.ByteOrder); err != nil {
+		return err
+	}
+	offset := len(s) + 8 + 4 + len(s)
+	cond := func(key []byte) bool {
+		if len(key) < offset+len(indexBs) {
+			return false
+		}
+		return string(key[offset:offset+len(indexBs)]) == string(indexBs)
+	}
+	return p.engine.PrefixEach(s, limit, cond, nil, fn)
+}
+
+func (p *operationParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+)
+
+// NullText is the null value in the csv export.
+const NullText = "__NULL__"
+
+// newVertexRow builds the vertex from the key.
+func newVertexRow(vid string, spaceID int32, schema schemacache.Schemacache) (*common.Row, error) {
+	v, err := vidValue(vid, spaceID, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &common.Row{Vid: v}, nil
+}
+
+// newEdgeRow builds the edge from the key.
+func newEdgeRow(src, dst string, rank int64, spaceID int32, schema schemacache.Schemacache) (*common.Row, error) {
+	s, err := vidValue(src, spaceID, schema)
+	if err != nil {
+		return nil, err
+	}
+	d, err := vidValue(dst, spaceID, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &common.Row{Edge: true, Vid: s, Dst: d, Rank: rank}, nil
+}
+
+// setRowValues sets the name and the properties of the tag or edge row.
+func setRowValues(r *common.Row, t string, spaceID, id int32, d *rowData, schema schemacache.Schemacache) error {
+	name, err := getSchemaName(t, spaceID, id, schema)
+	if err != nil {
+		return err
+	}
+	r.Name = name
+	for _, c := range d.dataset.ColumnNames {
+		r.Columns = append(r.Columns, string(c))
+	}
+	r.Types = d.types
+	r.Values = d.dataset.Rows[0].GetValues()
+	return nil
+}
+
+// RowValues returns the values of the row as nebula reads them for the export,
+// the padding of FIXED_STRING values is trimmed.
+func RowValues(r *common.Row) []*nebula.Value {
+	values := make([]*nebula.Value, len(r.Values))
+	for i, v := range r.Values {
+		if i < len(r.Types) {
+			v = trimValue(v, r.Types[i])
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// trimValue cuts the FIXED_STRING value at the first NUL byte like the strnlen of nebula,
+// other values are returned as is.
+func trimValue(v *nebula.Value, t nebula.PropertyType) *nebula.Value {
+	if t != nebula.PropertyType_FIXED_STRING || !v.IsSetSVal() {
+		return v
+	}
+	b := v.GetSVal()
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return &nebula.Value{SVal: b}
+}
+
+func getSchemaName(t string, spaceID, id int32, schema schemacache.Schemacache) (string, error) {
+	switch t {
+	case "tag":
+		for _, item := range schema.GetTags(spaceID) {
+			if item.TagID == id {
+				return string(item.TagName), nil
+			}
+		}
+	case "edge":
+		for _, item := range schema.GetEdges(spaceID) {
+			if item.EdgeType == id {
+				return string(item.EdgeName), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot find the name of %s %d", t, id)
+}
+
+// vidValue returns the int vid, or the string vid without the padding of the fixed length.
+func vidValue(vid string, spaceID int32, schema schemacache.Schemacache) (*nebula.Value, error) {
+	if schema.GetSpace(spaceID).GetProperties().GetVidType().GetType() == nebula.PropertyType_INT64 {
+		i, err := strconv.ParseInt(vid, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &nebula.Value{IVal: &i}, nil
+	}
+	return &nebula.Value{SVal: []byte(strings.TrimRight(vid, "\x00"))}, nil
+}
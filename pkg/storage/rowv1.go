@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+// rowReaderV1 decodes the rows written by nebula 1.x.
+// header: version length(3bit) + 00 + offset length - 1(3bit)
+// then version + block offsets(one for every 16 fields) + fields
+// there are no null bitmap, string heap and timestamp in a V1 row.
+type rowReaderV1 struct {
+	headerLength int32
+	schema       *meta.Schema
+	buf          []byte
+	pos          int32
+}
+
+func NewRowReaderV1(s *meta.Schema, buf []byte, versionLength int32) *rowReaderV1 {
+	offsetLength := int32(buf[0]&0x07) + 1
+	blocks := int32(0)
+	if n := len(s.GetColumns()); n > 0 {
+		blocks = int32((n - 1) >> 4)
+	}
+	// fields are read in order, so block offsets are only skipped
+	return &rowReaderV1{
+		schema:       s,
+		buf:          buf,
+		headerLength: 1 + versionLength + blocks*offsetLength,
+	}
+}
+
+func (r *rowReaderV1) read() (*nebula.Row, error) {
+	if int(r.headerLength) > len(r.buf) {
+		return nil, fmt.Errorf("row is truncated, header length is %d, length is %d", r.headerLength, len(r.buf))
+	}
+	values := make([]*nebula.Value, len(r.schema.Columns))
+	for i := 0; i < len(r.schema.Columns); i++ {
+		v, err := r.getValue(r.schema.Columns[i].GetType())
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return &nebula.Row{Values: values}, nil
+}
+
+func (r *rowReaderV1) next(l int32) ([]byte, error) {
+	offset := r.headerLength + r.pos
+	if l < 0 || int(offset+l) > len(r.buf) {
+		return nil, fmt.Errorf("row is truncated, offset is %d, length is %d", offset, len(r.buf))
+	}
+	r.pos += l
+	return r.buf[offset : offset+l], nil
+}
+
+func (r *rowReaderV1) varint() (int64, error) {
+	offset := r.headerLength + r.pos
+	if int(offset) > len(r.buf) {
+		return 0, fmt.Errorf("row is truncated, offset is %d, length is %d", offset, len(r.buf))
+	}
+	v, n := binary.Uvarint(r.buf[offset:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint at %d", offset)
+	}
+	r.pos += int32(n)
+	return int64(v), nil
+}
+
+func (r *rowReaderV1) getValue(t *meta.ColumnTypeDef) (*nebula.Value, error) {
+	v := nebula.NewValue()
+	switch t.GetType() {
+	case nebula.PropertyType_BOOL:
+		b, err := r.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return GetValue(b, nebula.PropertyType_BOOL)
+
+	// integers are varint encoded
+	case nebula.PropertyType_INT8, nebula.PropertyType_INT16, nebula.PropertyType_INT32,
+		nebula.PropertyType_INT64, nebula.PropertyType_TIMESTAMP:
+		value, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		v.SetIVal(&value)
+
+	case nebula.PropertyType_VID:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return GetValue(b, nebula.PropertyType_INT64)
+
+	case nebula.PropertyType_FLOAT:
+		b, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return GetValue(b, nebula.PropertyType_FLOAT)
+
+	case nebula.PropertyType_DOUBLE:
+		b, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return GetValue(b, nebula.PropertyType_DOUBLE)
+
+	// string length is varint encoded
+	case nebula.PropertyType_STRING:
+		l, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if l > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid string length %d", l)
+		}
+		b, err := r.next(int32(l))
+		if err != nil {
+			return nil, err
+		}
+		v.SetSVal(b)
+
+	default:
+		return nil, fmt.Errorf("not support this tpye in v1 row: %d", t.GetType())
+	}
+	return v, nil
+}
+
+// isRowV1 checks the row header, V2 row has 0x08 in the header.
+func isRowV1(header byte) (bool, error) {
+	switch header & 0x18 {
+	case 0x08:
+		return false, nil
+	case 0x00:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid row header %d", header)
+	}
+}
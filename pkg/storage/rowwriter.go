@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+// rowWriter encodes a row as nebula RowWriterV2, it's the reverse of rowReader.
+// header: 0000 1 + version length(3bit), then version + null bitmap + fields + string heap + timestamp(8byte)
+type rowWriter struct {
+	schema  *meta.Schema
+	version int64
+}
+
+func NewRowWriter(s *meta.Schema, version int64) *rowWriter {
+	return &rowWriter{
+		schema:  s,
+		version: version,
+	}
+}
+
+// Write encodes the values in the order of the columns.
+func (w *rowWriter) Write(row *nebula.Row, timestamp int64) ([]byte, error) {
+	columns := w.schema.GetColumns()
+	if len(row.GetValues()) != len(columns) {
+		return nil, fmt.Errorf("row has %d values, schema has %d columns", len(row.GetValues()), len(columns))
+	}
+
+	// the version is written in the least bytes
+	var versionBytes []byte
+	for v := uint64(w.version); v != 0; v >>= 8 {
+		versionBytes = append(versionBytes, byte(v))
+	}
+	buf := append([]byte{0x08 | byte(len(versionBytes))}, versionBytes...)
+
+	nullCount := 0
+	fixedLength := int32(0)
+	for _, c := range columns {
+		if c.GetNullable() {
+			nullCount++
+		}
+		l, err := getTypeLength(c.GetType())
+		if err != nil {
+			return nil, err
+		}
+		fixedLength += l
+	}
+	nullPos := len(buf)
+	if nullCount != 0 {
+		buf = append(buf, make([]byte, ((nullCount-1)>>3)+1)...)
+	}
+	pos := len(buf)
+	buf = append(buf, make([]byte, fixedLength)...)
+
+	nullFlagPos := 0
+	for i, c := range columns {
+		v := row.GetValues()[i]
+		l, _ := getTypeLength(c.GetType())
+		slot := buf[pos : pos+int(l)]
+		pos += int(l)
+		if c.GetNullable() {
+			flag := nullFlagPos
+			nullFlagPos++
+			// the slot of a null column is still reserved
+			if v == nil || v.IsSetNVal() {
+				buf[nullPos+(flag>>3)] |= 0x80 >> (flag & 0x07)
+				continue
+			}
+		}
+		if v == nil || v.IsSetNVal() {
+			return nil, fmt.Errorf("column %s is not nullable", c.GetName())
+		}
+		heap, err := putValue(slot, v, c.GetType())
+		if err != nil {
+			return nil, fmt.Errorf("column:%s, err: %w", c.GetName(), err)
+		}
+		if heap != nil {
+			// string offset(4byte) + string length(4byte), the offset is from the beginning of the row
+			common.ByteOrder.PutUint32(slot, uint32(len(buf)))
+			common.ByteOrder.PutUint32(slot[4:], uint32(len(heap)))
+			buf = append(buf, heap...)
+		}
+	}
+	ts := make([]byte, 8)
+	common.ByteOrder.PutUint64(ts, uint64(timestamp))
+	return append(buf, ts...), nil
+}
+
+// putValue writes the value into the slot in the fixed section,
+// and returns the bytes in the string heap for the types kept in the heap.
+func putValue(b []byte, v *nebula.Value, t *meta.ColumnTypeDef) ([]byte, error) {
+	switch t.GetType() {
+	case nebula.PropertyType_BOOL:
+		if !v.IsSetBVal() {
+			return nil, valueTypeError(v, t)
+		}
+		if v.GetBVal() {
+			b[0] = 1
+		}
+
+	case nebula.PropertyType_INT8, nebula.PropertyType_INT16, nebula.PropertyType_INT32,
+		nebula.PropertyType_INT64, nebula.PropertyType_TIMESTAMP:
+		if !v.IsSetIVal() {
+			return nil, valueTypeError(v, t)
+		}
+		return nil, putInt(b, v.GetIVal())
+
+	case nebula.PropertyType_FLOAT:
+		if !v.IsSetFVal() {
+			return nil, valueTypeError(v, t)
+		}
+		common.ByteOrder.PutUint32(b, math.Float32bits(float32(v.GetFVal())))
+
+	case nebula.PropertyType_DOUBLE:
+		if !v.IsSetFVal() {
+			return nil, valueTypeError(v, t)
+		}
+		common.ByteOrder.PutUint64(b, math.Float64bits(v.GetFVal()))
+
+	// the longer string is truncated, and the shorter one is padded with 0
+	case nebula.PropertyType_FIXED_STRING:
+		if !v.IsSetSVal() {
+			return nil, valueTypeError(v, t)
+		}
+		copy(b, v.GetSVal())
+
+	case nebula.PropertyType_STRING:
+		if !v.IsSetSVal() {
+			return nil, valueTypeError(v, t)
+		}
+		return v.GetSVal(), nil
+
+	case nebula.PropertyType_DATE:
+		if !v.IsSetDVal() {
+			return nil, valueTypeError(v, t)
+		}
+		putDate(b, v.GetDVal(), common.ByteOrder)
+
+	case nebula.PropertyType_TIME:
+		if !v.IsSetTVal() {
+			return nil, valueTypeError(v, t)
+		}
+		putTime(b, v.GetTVal(), common.ByteOrder)
+
+	case nebula.PropertyType_DATETIME:
+		if !v.IsSetDtVal() {
+			return nil, valueTypeError(v, t)
+		}
+		dt := v.GetDtVal()
+		putDate(b, &nebula.Date{Year: dt.GetYear(), Month: dt.GetMonth(), Day: dt.GetDay()}, common.ByteOrder)
+		putTime(b[4:], &nebula.Time{Hour: dt.GetHour(), Minute: dt.GetMinute(), Sec: dt.GetSec(), Microsec: dt.GetMicrosec()}, common.ByteOrder)
+
+	case nebula.PropertyType_GEOGRAPHY:
+		if !v.IsSetGgVal() {
+			return nil, valueTypeError(v, t)
+		}
+		return writeWKB(v.GetGgVal())
+
+	// duration: seconds(8) + microseconds(4) + months(4)
+	case nebula.PropertyType_DURATION:
+		if !v.IsSetDuVal() {
+			return nil, valueTypeError(v, t)
+		}
+		d := v.GetDuVal()
+		common.ByteOrder.PutUint64(b, uint64(d.GetSeconds()))
+		common.ByteOrder.PutUint32(b[8:], uint32(d.GetMicroseconds()))
+		common.ByteOrder.PutUint32(b[12:], uint32(d.GetMonths()))
+
+	default:
+		return nil, fmt.Errorf("not support to write this tpye: %d", t.GetType())
+	}
+	return nil, nil
+}
+
+// putInt checks the range of the type by the length of the slot.
+func putInt(b []byte, i int64) error {
+	switch len(b) {
+	case 1:
+		if i < math.MinInt8 || i > math.MaxInt8 {
+			return fmt.Errorf("%d overflows int8", i)
+		}
+		b[0] = byte(i)
+	case 2:
+		if i < math.MinInt16 || i > math.MaxInt16 {
+			return fmt.Errorf("%d overflows int16", i)
+		}
+		common.ByteOrder.PutUint16(b, uint16(i))
+	case 4:
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return fmt.Errorf("%d overflows int32", i)
+		}
+		common.ByteOrder.PutUint32(b, uint32(i))
+	default:
+		common.ByteOrder.PutUint64(b, uint64(i))
+	}
+	return nil
+}
+
+// date: year(2) + month(1) + day(1)
+func putDate(b []byte, d *nebula.Date, order binary.ByteOrder) {
+	order.PutUint16(b, uint16(d.GetYear()))
+	b[2] = byte(d.GetMonth())
+	b[3] = byte(d.GetDay())
+}
+
+// time: hour(1) + minute(1) + second(1) + microsec(4)
+func putTime(b []byte, t *nebula.Time, order binary.ByteOrder) {
+	b[0] = byte(t.GetHour())
+	b[1] = byte(t.GetMinute())
+	b[2] = byte(t.GetSec())
+	order.PutUint32(b[3:], uint32(t.GetMicrosec()))
+}
+
+func valueTypeError(v *nebula.Value, t *meta.ColumnTypeDef) error {
+	return fmt.Errorf("cannot write %s as %s", FormatValue(v), t.GetType())
+}
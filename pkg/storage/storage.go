@@ -2,22 +2,32 @@ package storage
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/harrischu/nebula-dump/pkg"
 	"github.com/harrischu/nebula-dump/pkg/common"
+	metadata "github.com/harrischu/nebula-dump/pkg/meta"
 	"github.com/harrischu/nebula-dump/pkg/schemacache"
 	"github.com/vesoft-inc/nebula-go/v3/nebula"
 	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
 )
 
 var (
-	kTag   int32 = 0x00000001
-	kEdge  int32 = 0x00000002
-	kIndex int32 = 0x00000003
+	kTag       int32 = 0x00000001
+	kEdge      int32 = 0x00000002
+	kIndex     int32 = 0x00000003
+	kSystem    int32 = 0x00000004
+	kOperation int32 = 0x00000005
+	kKeyValue  int32 = 0x00000006
+	kVertex    int32 = 0x00000007
+	// keys of the chained edges(TOSS) in nebula 3.x
+	kPrime       int32 = 0x00000008
+	kDoublePrime int32 = 0x00000009
 )
 
 type rowReader struct {
@@ -29,9 +39,28 @@ type rowReader struct {
 }
 
 type rowData struct {
-	version   int64
-	dataset   *nebula.DataSet
+	version int64
+	// the schema version used to decode the row, differs from version
+	// when decoding with the nearest older schema.
+	schemaVersion int64
+	dataset       *nebula.DataSet
+	// property types of the columns in dataset
+	types     []nebula.PropertyType
 	timestamp int64
+	// the schema has ttl, and whether the row is expired
+	ttl     bool
+	expired bool
+}
+
+// schemaNotFoundError is returned when the schema version of a row is missing.
+type schemaNotFoundError struct {
+	t       string
+	id      int32
+	version int64
+}
+
+func (e *schemaNotFoundError) Error() string {
+	return fmt.Sprintf("cannot get the schema, %s:%d, version:%d", e.t, e.id, e.version)
 }
 
 func NewRowReader(s *meta.Schema, buf []byte, headerLength int32) *rowReader {
@@ -60,18 +89,86 @@ func init() {
 		pkg.StorageKeyTypeMap[pkg.StorageKeyTags] = &tagParser{}
 		pkg.StorageKeyTypeMap[pkg.StorageKeyEdges] = &edgeParser{}
 		pkg.StorageKeyTypeMap[pkg.StorageKeyIndexes] = &indexParser{}
+		pkg.StorageKeyTypeMap[pkg.StorageKeyVertices] = &vertexParser{}
+		pkg.StorageKeyTypeMap[pkg.StorageKeyOperations] = &operationParser{}
+		pkg.StorageKeyTypeMap[pkg.StorageKeySystem] = &systemParser{}
+		pkg.StorageKeyTypeMap[pkg.StorageKeyKV] = &kvParser{}
 	}
 }
 
-// must provide space id, meta address for storage parser.
+// must provide space id, meta address or meta path for storage parser.
 func verifyOption(opt *pkg.Option) error {
 	if opt.SpaceID == -1 {
 		return fmt.Errorf("must provide a valid space id")
 	}
-	if opt.MetaAddres == "" {
-		return fmt.Errorf("must provide a valid meta address")
+	if opt.MetaAddres == "" && opt.MetaPath == "" {
+		return fmt.Errorf("must provide a valid meta address or meta path")
+	}
+	switch opt.MissingSchema {
+	case "", pkg.MissingSchemaFail, pkg.MissingSchemaSkip, pkg.MissingSchemaNearest:
+	default:
+		return fmt.Errorf("invalid missing schema policy %s", opt.MissingSchema)
+	}
+	if err := verifyTTLOption(opt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NewSchemaCache loads the schema from the meta rocksdb of MetaPath if it's set,
+// otherwise from the metad of MetaAddres, or its schema cache if offline or metad is unreachable.
+func NewSchemaCache(opts *pkg.Option) (schemacache.Schemacache, error) {
+	var (
+		schema schemacache.Schemacache
+		err    error
+	)
+	switch {
+	case opts.MetaPath != "":
+		schema, err = metadata.NewRocksdbCache(opts.MetaPath)
+	case opts.MetaAddres != "":
+		schema, err = schemacache.LoadFileCache(opts.MetaAddres, opts.Offline)
+	default:
+		return nil, fmt.Errorf("must provide a valid meta address or meta path")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Update(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// loadSchema loads the schema from meta, it's only loaded once for a parser.
+func loadSchema(schema schemacache.Schemacache, opts *pkg.Option) (schemacache.Schemacache, error) {
+	if schema != nil {
+		return schema, nil
+	}
+	return NewSchemaCache(opts)
+}
+
+// verifyAllParts checks the options which select a part can't be used with all parts.
+func verifyAllParts(opts *pkg.Option) error {
+	if opts.AllParts && (opts.PartID != -1 || opts.VID != "" || opts.Src != "" || opts.Dst != "") {
+		return fmt.Errorf("cannot use all parts with part, vid, src or dst")
 	}
+	return nil
+}
 
+// prefixAllParts calls iterate for every part of the space with the limit left,
+// and passes the kvs of all parts to fn. the limit is for all parts.
+func prefixAllParts(space *meta.SpaceItem, limit int, iterate func(part int32, limit int, fn func(*common.KV) error) error, fn func(*common.KV) error) error {
+	count := 0
+	counted := func(kv *common.KV) error {
+		count++
+		return fn(kv)
+	}
+	for part := int32(1); part <= space.GetProperties().GetPartitionNum() && count < limit; part++ {
+		if err := iterate(part, limit-count, counted); err != nil {
+			return fmt.Errorf("part:%d, err: %w", part, err)
+		}
+	}
 	return nil
 }
 
@@ -126,84 +223,251 @@ func getVidString(vid []byte, spaceID int32, schema schemacache.Schemacache) (st
 	return s, nil
 }
 
-func decodeValue(t string, value []byte, spaceID, id int32, schema schemacache.Schemacache) (*rowData, error) {
-	var (
-		version      int64
-		headerLength int32
-	)
-	var vb []byte = make([]byte, 8)
+// getRowVersion decodes the row header.
+// V2 header: 0000 1 + version length(3bit), V1 header: version length(3bit) + 00 + offset length(3bit)
+func getRowVersion(value []byte) (version int64, versionLength int32, v1 bool, err error) {
+	var l byte
+	if len(value) == 0 {
+		return 0, 0, false, fmt.Errorf("empty row")
+	}
 	b := value[0]
-	l := b & 0x07
-	if l == 0 {
-		version = 0
-		headerLength = 1
+	if v1, err = isRowV1(b); err != nil {
+		return 0, 0, false, err
+	}
+	if v1 {
+		l = b >> 5
 	} else {
-		for i := 0; i < int(l); i++ {
-			vb[i] = value[1+i]
-		}
+		l = b & 0x07
+	}
+	if int(l)+1 > len(value) {
+		return 0, 0, false, fmt.Errorf("row is truncated, version length is %d, length is %d", l, len(value))
+	}
+	if l != 0 {
+		var vb []byte = make([]byte, 8)
+		copy(vb, value[1:1+l])
 		if err := common.ConvertBytesToInt(&version, &vb, common.ByteOrder); err != nil {
-			return nil, err
+			return 0, 0, false, err
 		}
-		headerLength = int32(l) + 1
-	}
-	s := getSchema(t, spaceID, id, version, schema)
-	if s == nil {
-		return nil, fmt.Errorf("cannot get the schema")
 	}
+	return version, int32(l), v1, nil
+}
 
-	r := NewRowReader(s, value, headerLength)
-	row, err := r.read()
+func decodeValue(t string, value []byte, spaceID, id int32, schema schemacache.Schemacache, policy string) (*rowData, error) {
+	var (
+		row       *nebula.Row
+		timestamp int64
+	)
+	version, l, v1, err := getRowVersion(value)
 	if err != nil {
 		return nil, err
 	}
+	headerLength := l + 1
+	s, schemaVersion := getSchema(t, spaceID, id, version, schema)
+	if s == nil && policy == pkg.MissingSchemaNearest {
+		s, schemaVersion = getNearestSchema(t, spaceID, id, version, schema)
+		if s != nil {
+			common.Logger.Debugf("%s:%d, version:%d is missing, use version:%d", t, id, version, schemaVersion)
+		}
+	}
+	if s == nil {
+		return nil, &schemaNotFoundError{t: t, id: id, version: version}
+	}
+
+	if v1 {
+		row, err = NewRowReaderV1(s, value, l).read()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		row, err = NewRowReader(s, value, headerLength).read()
+		if err != nil {
+			return nil, err
+		}
+		if len(value) < int(headerLength)+8 {
+			return nil, fmt.Errorf("row is truncated, cannot read the timestamp")
+		}
+		ts := value[len(value)-8:]
+		if err := common.ConvertBytesToInt(&timestamp, &ts, common.ByteOrder); err != nil {
+			return nil, err
+		}
+	}
 	ds := nebula.NewDataSet()
+	types := make([]nebula.PropertyType, 0, len(s.GetColumns()))
 	for _, c := range s.GetColumns() {
 		ds.ColumnNames = append(ds.ColumnNames, c.GetName())
+		types = append(types, c.GetType().GetType())
 	}
-	ts := value[len(value)-8:]
-	var timestamp int64
-	if err := common.ConvertBytesToInt(&timestamp, &ts, common.ByteOrder); err != nil {
-		return nil, err
+	if latest := getLatestSchema(t, spaceID, id, schema); latest != nil && latest != s {
+		types = append(types, fillMissingColumns(ds, row, latest)...)
 	}
 	ds.Rows = append(ds.Rows, row)
 	data := &rowData{
-		version:   version,
-		dataset:   ds,
-		timestamp: timestamp,
+		version:       version,
+		schemaVersion: schemaVersion,
+		dataset:       ds,
+		types:         types,
+		timestamp:     timestamp,
 	}
 	return data, nil
 
 }
 
-func getSchema(t string, spaceID, id int32, version int64, schema schemacache.Schemacache) *meta.Schema {
-	var s *meta.Schema
+// schemaItem is the common part of TagItem and EdgeItem.
+type schemaItem struct {
+	version int64
+	schema  *meta.Schema
+}
+
+func getSchemaItems(t string, spaceID, id int32, schema schemacache.Schemacache) []schemaItem {
+	items := make([]schemaItem, 0)
 	switch t {
 	case "tag":
-		tags := schema.GetTags(spaceID)
-		for _, t := range tags {
-			if t.TagID == id && t.Version == version {
-				s = t.Schema
-				break
+		for _, t := range schema.GetTags(spaceID) {
+			if t.TagID == id {
+				items = append(items, schemaItem{t.Version, t.Schema})
 			}
 		}
 
 	case "edge":
-		edges := schema.GetEdges(spaceID)
-		for _, t := range edges {
-			if t.EdgeType == id && t.Version == version {
-				s = t.Schema
-				break
+		for _, t := range schema.GetEdges(spaceID) {
+			if t.EdgeType == id {
+				items = append(items, schemaItem{t.Version, t.Schema})
 			}
 		}
 	}
+	return items
+}
+
+func getSchema(t string, spaceID, id int32, version int64, schema schemacache.Schemacache) (*meta.Schema, int64) {
+	for _, item := range getSchemaItems(t, spaceID, id, schema) {
+		if item.version == version {
+			return item.schema, item.version
+		}
+	}
+	return nil, 0
+}
+
+// getNearestSchema returns the newest schema which is older than the version.
+func getNearestSchema(t string, spaceID, id int32, version int64, schema schemacache.Schemacache) (*meta.Schema, int64) {
+	var (
+		s *meta.Schema
+		v int64 = -1
+	)
+	for _, item := range getSchemaItems(t, spaceID, id, schema) {
+		if item.version < version && item.version > v {
+			s, v = item.schema, item.version
+		}
+	}
+	return s, v
+}
+
+func getLatestSchema(t string, spaceID, id int32, schema schemacache.Schemacache) *meta.Schema {
+	var (
+		s *meta.Schema
+		v int64 = -1
+	)
+	for _, item := range getSchemaItems(t, spaceID, id, schema) {
+		if item.version > v {
+			s, v = item.schema, item.version
+		}
+	}
 	return s
 }
 
+// formatRowData formats the properties of a tag or an edge row.
+func formatRowData(d *rowData) string {
+	valuse := make([]string, 0)
+	if d.schemaVersion != d.version {
+		valuse = append(valuse, fmt.Sprintf("version:%d(schema version:%d)", d.version, d.schemaVersion))
+	} else {
+		valuse = append(valuse, fmt.Sprintf("version:%d", d.version))
+	}
+	row := d.dataset.Rows[0]
+
+	for i := 0; i < len(d.dataset.ColumnNames); i++ {
+		valuse = append(valuse, fmt.Sprintf("%s:%s", d.dataset.ColumnNames[i], FormatValue(row.GetValues()[i])))
+	}
+	valuse = append(valuse, fmt.Sprintf("timestamp:%d", d.timestamp))
+	if d.ttl {
+		valuse = append(valuse, fmt.Sprintf("expired:%t", d.expired))
+	}
+	return strings.Join(valuse, ", ")
+}
+
+// addRowFields adds the row for the structured output, in the same order as formatRowData.
+func addRowFields(kvstring *common.KVString, d *rowData) {
+	kvstring.AddField("version", d.version)
+	if d.schemaVersion != d.version {
+		kvstring.AddField("schema_version", d.schemaVersion)
+	}
+	row := d.dataset.Rows[0]
+	for i := 0; i < len(d.dataset.ColumnNames); i++ {
+		addField(kvstring, string(d.dataset.ColumnNames[i]), fieldValue(row.GetValues()[i]))
+	}
+	kvstring.AddField("timestamp", d.timestamp)
+	if d.ttl {
+		kvstring.AddField("expired", d.expired)
+	}
+}
+
+// addField adds a property, the name is prefixed with "prop." if it exists, e.g. a property named vid.
+func addField(kvstring *common.KVString, name string, value interface{}) {
+	for _, f := range kvstring.Fields {
+		if f.Name == name {
+			name = "prop." + name
+			break
+		}
+	}
+	kvstring.AddField(name, value)
+}
+
+// fieldValue converts the value for the structured output,
+// null is nil, and the types without a json type are formatted as FormatValue.
+func fieldValue(value *nebula.Value) interface{} {
+	switch {
+	case value.IsSetNVal():
+		return nil
+	case value.IsSetBVal():
+		return value.GetBVal()
+	case value.IsSetIVal():
+		return value.GetIVal()
+	case value.IsSetFVal() && !math.IsNaN(value.GetFVal()) && !math.IsInf(value.GetFVal(), 0):
+		return value.GetFVal()
+	case value.IsSetSVal():
+		return string(value.GetSVal())
+	default:
+		return FormatValue(value)
+	}
+}
+
+// skipMissingSchema checks whether the error could be ignored by the policy.
+func skipMissingSchema(err error, policy string) bool {
+	var e *schemaNotFoundError
+	if policy == pkg.MissingSchemaSkip && errors.As(err, &e) {
+		common.Logger.Warnf("skip the row, %v", err)
+		return true
+	}
+	return false
+}
+
 func (r *rowReader) read() (*nebula.Row, error) {
+	var nullFlagPos int32
 	values := make([]*nebula.Value, len(r.schema.Columns))
 	for i := 0; i < len(r.schema.Columns); i++ {
 		f := r.schema.Columns[i]
 		t := f.GetType()
+		if f.GetNullable() {
+			null := r.isNull(nullFlagPos)
+			nullFlagPos++
+			// the slot of a null column is still reserved in the row
+			if null {
+				if err := r.skipValue(t); err != nil {
+					return nil, err
+				}
+				values[i] = newNullValue(nebula.NullType___NULL__)
+				continue
+			}
+		}
 		v, err := r.getValue(t)
 		if err != nil {
 			return nil, err
@@ -222,63 +486,85 @@ func (r *rowReader) isNull(pos int32) bool {
 	return flag != 0
 }
 
+func (r *rowReader) skipValue(t *meta.ColumnTypeDef) error {
+	l, err := getTypeLength(t)
+	if err != nil {
+		return err
+	}
+	r.pos += l
+	return nil
+}
+
 func (r *rowReader) getValue(t *meta.ColumnTypeDef) (*nebula.Value, error) {
-	var b []byte
+	l, err := getTypeLength(t)
+	if err != nil {
+		return nil, err
+	}
 	offset := r.headerLength + r.nullBytesLength + r.pos
-	switch t.GetType() {
-	case nebula.PropertyType_BOOL:
-		b = r.buf[offset : offset+1]
-		r.pos += 1
-
-	case nebula.PropertyType_INT8:
-		b = r.buf[offset : offset+1]
-		r.pos += 1
-
-	case nebula.PropertyType_INT16:
-		b = r.buf[offset : offset+2]
-		r.pos += 2
-
-	case nebula.PropertyType_INT32:
-		b = r.buf[offset : offset+4]
-		r.pos += 4
-
-	case nebula.PropertyType_INT64:
-		b = r.buf[offset : offset+8]
-		r.pos += 8
-
-	case nebula.PropertyType_FIXED_STRING:
-		b = r.buf[offset : offset+int32(t.GetTypeLength())]
-		r.pos += int32(t.GetTypeLength())
+	if int(offset+l) > len(r.buf) {
+		return nil, fmt.Errorf("row is truncated, offset is %d, length is %d", offset, len(r.buf))
+	}
+	b := r.buf[offset : offset+l]
+	r.pos += l
 
-	case nebula.PropertyType_STRING:
+	switch t.GetType() {
+	// string offset(4bit) + string length(4bit), the string is in the heap
+	case nebula.PropertyType_STRING, nebula.PropertyType_GEOGRAPHY:
 		var (
 			strOffset int32
 			strLen    int32
 		)
-		d := r.buf[offset : offset+int32(common.Sizeof(strOffset))]
+		d, l := b[:common.Sizeof(strOffset)], b[common.Sizeof(strOffset):]
 		if err := common.ConvertBytesToInt(&strOffset, &d, common.ByteOrder); err != nil {
 			return nil, err
 		}
-		r.pos += int32(common.Sizeof(strOffset))
-		l := r.buf[offset+int32(common.Sizeof(strOffset)) : offset+int32(common.Sizeof(strOffset))+int32(common.Sizeof(strLen))]
-
 		if err := common.ConvertBytesToInt(&strLen, &l, common.ByteOrder); err != nil {
 			return nil, err
 		}
-		r.pos += int32(common.Sizeof(strLen))
+		if strOffset < 0 || strLen < 0 || int(strOffset)+int(strLen) > len(r.buf) {
+			return nil, fmt.Errorf("invalid string, offset is %d, length is %d", strOffset, strLen)
+		}
 		b = r.buf[strOffset : strOffset+strLen]
+	}
+	return GetValue(b, t.GetType())
+}
 
+// getTypeLength returns the length of the type in the fixed section of a row.
+func getTypeLength(t *meta.ColumnTypeDef) (int32, error) {
+	var l int32
+	switch t.GetType() {
+	case nebula.PropertyType_BOOL, nebula.PropertyType_INT8:
+		l = 1
+	case nebula.PropertyType_INT16:
+		l = 2
+	case nebula.PropertyType_INT32, nebula.PropertyType_FLOAT:
+		l = 4
+	case nebula.PropertyType_INT64, nebula.PropertyType_DOUBLE, nebula.PropertyType_TIMESTAMP:
+		l = 8
+	case nebula.PropertyType_FIXED_STRING:
+		l = int32(t.GetTypeLength())
+	case nebula.PropertyType_STRING, nebula.PropertyType_GEOGRAPHY:
+		l = 4 + 4
 	case nebula.PropertyType_DATETIME:
-		b = r.buf[offset : offset+2+1+1+1+1+1+4]
-		r.pos += 2 + 1 + 1 + 1 + 1 + 1 + 4
+		l = 2 + 1 + 1 + 1 + 1 + 1 + 4
+	case nebula.PropertyType_DATE:
+		l = 2 + 1 + 1
+	case nebula.PropertyType_TIME:
+		l = 1 + 1 + 1 + 4
+	case nebula.PropertyType_DURATION:
+		l = 8 + 4 + 4
 	default:
-		return nil, fmt.Errorf("not support this tpye: %d", t.GetType())
+		return 0, fmt.Errorf("not support this tpye: %d", t.GetType())
 	}
-	return GetValue(b, t.GetType())
+	return l, nil
+}
+
+func newNullValue(t nebula.NullType) *nebula.Value {
+	return nebula.NewValue().SetNVal(&t)
 }
 
-// copy from nebula-go
-func formatValue(value *nebula.Value) string {
+// FormatValue formats the value as the text output, copy from nebula-go
+func FormatValue(value *nebula.Value) string {
 	if value.IsSetNVal() {
 		return value.GetNVal().String()
 	} else if value.IsSetBVal() {
@@ -286,8 +572,10 @@ func formatValue(value *nebula.Value) string {
 	} else if value.IsSetIVal() {
 		return fmt.Sprintf("%d", value.GetIVal())
 	} else if value.IsSetFVal() {
-		fStr := strconv.FormatFloat(value.GetFVal(), 'g', -1, 64)
-		if !strings.Contains(fStr, ".") {
+		f := value.GetFVal()
+		fStr := strconv.FormatFloat(f, 'g', -1, 64)
+		// NaN and infinity have no fractional part to append
+		if !strings.Contains(fStr, ".") && !math.IsNaN(f) && !math.IsInf(f, 0) {
 			fStr = fStr + ".0"
 		}
 		return fStr
@@ -327,7 +615,7 @@ func formatValue(value *nebula.Value) string {
 		lval := value.GetLVal()
 		var strs []string
 		for _, val := range lval.Values {
-			strs = append(strs, formatValue(val))
+			strs = append(strs, FormatValue(val))
 		}
 		return fmt.Sprintf("[%s]", strings.Join(strs, ", "))
 	} else if value.IsSetMVal() { // Map
@@ -341,13 +629,19 @@ func formatValue(value *nebula.Value) string {
 		}
 		sort.Strings(keyList)
 		for _, k := range keyList {
-			output = append(output, fmt.Sprintf("%s: %s", k, formatValue(kvs[k])))
+			output = append(output, fmt.Sprintf("%s: %s", k, FormatValue(kvs[k])))
 		}
 		return fmt.Sprintf("{%s}", strings.Join(output, ", "))
 	} else if value.IsSetUVal() {
-		return "not support yet"
+		// set to string
+		uval := value.GetUVal()
+		var strs []string
+		for _, val := range uval.Values {
+			strs = append(strs, FormatValue(val))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(strs, ", "))
 	} else if value.IsSetGgVal() {
-		return "not support yet"
+		return toWKT(value.GetGgVal())
 	} else if value.IsSetDuVal() {
 		duval := value.GetDuVal()
 		totalSeconds := duval.GetSeconds() + int64(duval.GetMicroseconds())/1000000
@@ -401,7 +695,7 @@ func GetValue(b []byte, t nebula.PropertyType) (*nebula.Value, error) {
 		v.IVal = &value
 		v.SetIVal(&value)
 
-	case nebula.PropertyType_INT64:
+	case nebula.PropertyType_INT64, nebula.PropertyType_TIMESTAMP:
 		var value int64
 		if err := common.ConvertBytesToInt(&value, &b, common.ByteOrder); err != nil {
 			return nil, err
@@ -409,10 +703,39 @@ func GetValue(b []byte, t nebula.PropertyType) (*nebula.Value, error) {
 		v.IVal = &value
 		v.SetIVal(&value)
 
+	case nebula.PropertyType_FLOAT:
+		var bits uint32
+		if err := common.ConvertBytesToInt(&bits, &b, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		value := float64(math.Float32frombits(bits))
+		v.SetFVal(&value)
+
+	case nebula.PropertyType_DOUBLE:
+		var bits uint64
+		if err := common.ConvertBytesToInt(&bits, &b, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		value := math.Float64frombits(bits)
+		v.SetFVal(&value)
+
 	case nebula.PropertyType_FIXED_STRING:
 		v.SetSVal(b)
 	case nebula.PropertyType_STRING:
 		v.SetSVal(b)
+	case nebula.PropertyType_GEOGRAPHY:
+		g, err := parseWKB(b)
+		if err != nil {
+			return nil, err
+		}
+		v.SetGgVal(g)
+
+	case nebula.PropertyType_DURATION:
+		d, err := getDuration(b)
+		if err != nil {
+			return nil, err
+		}
+		v.SetDuVal(d)
 
 	case nebula.PropertyType_DATETIME:
 		var (
@@ -463,6 +786,20 @@ func GetValue(b []byte, t nebula.PropertyType) (*nebula.Value, error) {
 		dt.SetMicrosec(microsec)
 		v.SetDtVal(dt)
 
+	case nebula.PropertyType_DATE:
+		date, err := getDate(b, common.ByteOrder)
+		if err != nil {
+			return nil, err
+		}
+		v.SetDVal(date)
+
+	case nebula.PropertyType_TIME:
+		tm, err := getTime(b, common.ByteOrder)
+		if err != nil {
+			return nil, err
+		}
+		v.SetTVal(tm)
+
 	default:
 		return nil, fmt.Errorf("not support this tpye: %d", t)
 	}
@@ -489,7 +826,7 @@ func GetIndexValue(b []byte, t nebula.PropertyType) (*nebula.Value, error) {
 	case nebula.PropertyType_INT32:
 		fallthrough
 
-	case nebula.PropertyType_INT64:
+	case nebula.PropertyType_INT64, nebula.PropertyType_TIMESTAMP:
 		var value uint64
 		if err := common.ConvertBytesToInt(&value, &b, binary.BigEndian); err != nil {
 			return nil, err
@@ -503,6 +840,8 @@ func GetIndexValue(b []byte, t nebula.PropertyType) (*nebula.Value, error) {
 		v.SetSVal(b)
 	case nebula.PropertyType_STRING:
 		v.SetSVal(b)
+	case nebula.PropertyType_GEOGRAPHY:
+		return getGeoIndexValue(b)
 
 	case nebula.PropertyType_DATETIME:
 		var (
@@ -553,6 +892,20 @@ func GetIndexValue(b []byte, t nebula.PropertyType) (*nebula.Value, error) {
 		dt.SetMicrosec(microsec)
 		v.SetDtVal(dt)
 
+	case nebula.PropertyType_DATE:
+		date, err := getDate(b, binary.BigEndian)
+		if err != nil {
+			return nil, err
+		}
+		v.SetDVal(date)
+
+	case nebula.PropertyType_TIME:
+		tm, err := getTime(b, binary.BigEndian)
+		if err != nil {
+			return nil, err
+		}
+		v.SetTVal(tm)
+
 	default:
 		return nil, fmt.Errorf("not support this tpye: %d", t)
 	}
@@ -568,12 +921,19 @@ func getIndexTypeLength(t nebula.PropertyType) (int, error) {
 		fallthrough
 	case nebula.PropertyType_INT32:
 		fallthrough
-	case nebula.PropertyType_INT64:
+	case nebula.PropertyType_INT64, nebula.PropertyType_TIMESTAMP:
 		l = 8
 	case nebula.PropertyType_BOOL:
 		l = 1
+	// s2 cell id
+	case nebula.PropertyType_GEOGRAPHY:
+		l = 8
 	case nebula.PropertyType_DATETIME:
 		l = 2 + 1 + 1 + 1 + 1 + 1 + 4
+	case nebula.PropertyType_DATE:
+		l = 2 + 1 + 1
+	case nebula.PropertyType_TIME:
+		l = 1 + 1 + 1 + 4
 
 	default:
 		return 0, fmt.Errorf("unsupport type, type is %v", t)
@@ -581,3 +941,80 @@ func getIndexTypeLength(t nebula.PropertyType) (int, error) {
 	return l, nil
 
 }
+
+// date: year(2) + month(1) + day(1)
+func getDate(b []byte, order binary.ByteOrder) (*nebula.Date, error) {
+	var (
+		year  int16
+		month int8
+		day   int8
+	)
+	y, m, d := b[:2], b[2:2+1], b[2+1:2+1+1]
+	if err := common.ConvertBytesToInt(&year, &y, order); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&month, &m, order); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&day, &d, order); err != nil {
+		return nil, err
+	}
+	date := nebula.NewDate()
+	date.SetYear(year)
+	date.SetMonth(month)
+	date.SetDay(day)
+	return date, nil
+}
+
+// time: hour(1) + minute(1) + second(1) + microsec(4)
+func getTime(b []byte, order binary.ByteOrder) (*nebula.Time, error) {
+	var (
+		hour     int8
+		minute   int8
+		second   int8
+		microsec int32
+	)
+	h, mi, s, ms := b[:1], b[1:1+1], b[1+1:1+1+1], b[1+1+1:1+1+1+4]
+	if err := common.ConvertBytesToInt(&hour, &h, order); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&minute, &mi, order); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&second, &s, order); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&microsec, &ms, order); err != nil {
+		return nil, err
+	}
+	tm := nebula.NewTime()
+	tm.SetHour(hour)
+	tm.SetMinute(minute)
+	tm.SetSec(second)
+	tm.SetMicrosec(microsec)
+	return tm, nil
+}
+
+// duration: seconds(8) + microseconds(4) + months(4)
+func getDuration(b []byte) (*nebula.Duration, error) {
+	var (
+		seconds      int64
+		microseconds int32
+		months       int32
+	)
+	s, ms, m := b[:8], b[8:8+4], b[8+4:8+4+4]
+	if err := common.ConvertBytesToInt(&seconds, &s, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&microseconds, &ms, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&months, &m, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	d := nebula.NewDuration()
+	d.SetSeconds(seconds)
+	d.SetMicroseconds(microseconds)
+	d.SetMonths(months)
+	return d, nil
+}
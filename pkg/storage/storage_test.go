@@ -0,0 +1,937 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/vesoft-inc/nebula-go/v3/nebula"
+	"github.com/vesoft-inc/nebula-go/v3/nebula/meta"
+)
+
+func newColumn(name string, t nebula.PropertyType) *meta.ColumnDef {
+	c := meta.NewColumnDef()
+	c.Name = []byte(name)
+	c.Type = meta.NewColumnTypeDef()
+	c.Type.Type = t
+	return c
+}
+
+func TestFloatAndDouble(t *testing.T) {
+	cases := []struct {
+		f      float32
+		d      float64
+		expect []string
+	}{
+		{1.5, 3.25, []string{"1.5", "3.25"}},
+		{-2, -1024.125, []string{"-2.0", "-1024.125"}},
+		{float32(math.NaN()), math.NaN(), []string{"NaN", "NaN"}},
+		{float32(math.Inf(1)), math.Inf(-1), []string{"+Inf", "-Inf"}},
+	}
+	s := meta.NewSchema()
+	s.Columns = []*meta.ColumnDef{
+		newColumn("score", nebula.PropertyType_FLOAT),
+		newColumn("weight", nebula.PropertyType_DOUBLE),
+	}
+	for _, c := range cases {
+		buf := make([]byte, 1+4+8)
+		buf[0] = 0x08
+		common.ByteOrder.PutUint32(buf[1:], math.Float32bits(c.f))
+		common.ByteOrder.PutUint64(buf[5:], math.Float64bits(c.d))
+		row, err := NewRowReader(s, buf, 1).read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range row.GetValues() {
+			assert.True(t, v.IsSetFVal())
+			assert.Equal(t, c.expect[i], FormatValue(v))
+		}
+	}
+}
+
+func TestDateTimeAndTimestamp(t *testing.T) {
+	s := meta.NewSchema()
+	s.Columns = []*meta.ColumnDef{
+		newColumn("birthday", nebula.PropertyType_DATE),
+		newColumn("start", nebula.PropertyType_TIME),
+		newColumn("created", nebula.PropertyType_TIMESTAMP),
+	}
+	buf := make([]byte, 1+4+7+8)
+	buf[0] = 0x08
+	// date 2021-03-05
+	common.ByteOrder.PutUint16(buf[1:], 2021)
+	buf[3], buf[4] = 3, 5
+	// time 12:34:56.000789
+	buf[5], buf[6], buf[7] = 12, 34, 56
+	common.ByteOrder.PutUint32(buf[8:], 789)
+	common.ByteOrder.PutUint64(buf[12:], 1614902400)
+
+	row, err := NewRowReader(s, buf, 1).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := row.GetValues()
+	assert.True(t, values[0].IsSetDVal())
+	assert.Equal(t, "2021-03-05", FormatValue(values[0]))
+	assert.True(t, values[1].IsSetTVal())
+	assert.Equal(t, "12:34:56.000789", FormatValue(values[1]))
+	assert.True(t, values[2].IsSetIVal())
+	assert.Equal(t, "1614902400", FormatValue(values[2]))
+}
+
+func TestIndexDateTimeAndTimestamp(t *testing.T) {
+	l, err := getIndexTypeLength(nebula.PropertyType_DATE)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, l)
+	v, err := GetIndexValue([]byte{0x07, 0xe5, 3, 5}, nebula.PropertyType_DATE)
+	assert.NoError(t, err)
+	assert.Equal(t, "2021-03-05", FormatValue(v))
+
+	l, err = getIndexTypeLength(nebula.PropertyType_TIME)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, l)
+	v, err = GetIndexValue([]byte{12, 34, 56, 0, 0, 0x03, 0x15}, nebula.PropertyType_TIME)
+	assert.NoError(t, err)
+	assert.Equal(t, "12:34:56.000789", FormatValue(v))
+
+	l, err = getIndexTypeLength(nebula.PropertyType_TIMESTAMP)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, l)
+	// index int values are big endian with the sign bit flipped
+	v, err = GetIndexValue([]byte{0x80, 0, 0, 0, 0x60, 0x41, 0x6f, 0x80}, nebula.PropertyType_TIMESTAMP)
+	assert.NoError(t, err)
+	assert.Equal(t, "1614901120", FormatValue(v))
+}
+
+func appendWKB(buf []byte, shape uint32, coords ...float64) []byte {
+	b := make([]byte, 4)
+	buf = append(buf, 1)
+	binary.LittleEndian.PutUint32(b, shape)
+	buf = append(buf, b...)
+	if shape != wkbPoint {
+		binary.LittleEndian.PutUint32(b, uint32(len(coords)/2))
+		buf = append(buf, b...)
+	}
+	for _, c := range coords {
+		d := make([]byte, 8)
+		binary.LittleEndian.PutUint64(d, math.Float64bits(c))
+		buf = append(buf, d...)
+	}
+	return buf
+}
+
+func TestGeography(t *testing.T) {
+	// a polygon with one ring, the ring has the same layout as a linestring body
+	ring := appendWKB(nil, wkbLineString, 0, 0, 1, 0, 1, 1, 0, 0)[1+4:]
+	polygon := append([]byte{1, 3, 0, 0, 0, 1, 0, 0, 0}, ring...)
+	cases := []struct {
+		wkb    []byte
+		expect string
+	}{
+		{appendWKB(nil, wkbPoint, 116.3, 39.9), "POINT(116.3 39.9)"},
+		{appendWKB(nil, wkbLineString, 0, 1, 2, -3.5), "LINESTRING(0 1, 2 -3.5)"},
+		{polygon, "POLYGON((0 0, 1 0, 1 1, 0 0))"},
+	}
+	s := meta.NewSchema()
+	s.Columns = []*meta.ColumnDef{newColumn("location", nebula.PropertyType_GEOGRAPHY)}
+	for _, c := range cases {
+		// header + offset + length + heap
+		buf := make([]byte, 1+4+4)
+		buf[0] = 0x08
+		common.ByteOrder.PutUint32(buf[1:], uint32(len(buf)))
+		common.ByteOrder.PutUint32(buf[5:], uint32(len(c.wkb)))
+		buf = append(buf, c.wkb...)
+		row, err := NewRowReader(s, buf, 1).read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, row.GetValues()[0].IsSetGgVal())
+		assert.Equal(t, c.expect, FormatValue(row.GetValues()[0]))
+	}
+}
+
+func TestGeoIndex(t *testing.T) {
+	l, err := getIndexTypeLength(nebula.PropertyType_GEOGRAPHY)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, l)
+
+	// face 0, level 0
+	v, err := GetIndexValue([]byte{0x10, 0, 0, 0, 0, 0, 0, 0}, nebula.PropertyType_GEOGRAPHY)
+	assert.NoError(t, err)
+	kvs := v.GetMVal().GetKvs()
+	assert.Equal(t, "1152921504606846976", string(kvs["cell"].GetSVal()))
+	assert.Equal(t, int64(0), kvs["level"].GetIVal())
+	assert.InDelta(t, -35.264, kvs["lat"].GetLVal().Values[0].GetFVal(), 1e-3)
+	assert.InDelta(t, 35.264, kvs["lat"].GetLVal().Values[1].GetFVal(), 1e-3)
+	assert.InDelta(t, -45, kvs["lng"].GetLVal().Values[0].GetFVal(), 1e-9)
+	assert.InDelta(t, 45, kvs["lng"].GetLVal().Values[1].GetFVal(), 1e-9)
+
+	// the first child of face 0
+	v, err = GetIndexValue([]byte{0x04, 0, 0, 0, 0, 0, 0, 0}, nebula.PropertyType_GEOGRAPHY)
+	assert.NoError(t, err)
+	kvs = v.GetMVal().GetKvs()
+	assert.Equal(t, int64(1), kvs["level"].GetIVal())
+	assert.InDelta(t, -45, kvs["lat"].GetLVal().Values[0].GetFVal(), 1e-9)
+	assert.InDelta(t, 0, kvs["lat"].GetLVal().Values[1].GetFVal(), 1e-9)
+	assert.InDelta(t, -45, kvs["lng"].GetLVal().Values[0].GetFVal(), 1e-9)
+	assert.InDelta(t, 0, kvs["lng"].GetLVal().Values[1].GetFVal(), 1e-9)
+
+	// face 3 crosses the antimeridian, the lng range wraps
+	v, err = GetIndexValue([]byte{0x70, 0, 0, 0, 0, 0, 0, 0}, nebula.PropertyType_GEOGRAPHY)
+	assert.NoError(t, err)
+	kvs = v.GetMVal().GetKvs()
+	assert.InDelta(t, 135, kvs["lng"].GetLVal().Values[0].GetFVal(), 1e-9)
+	assert.InDelta(t, -135, kvs["lng"].GetLVal().Values[1].GetFVal(), 1e-9)
+
+	// face 2 contains the north pole
+	v, err = GetIndexValue([]byte{0x50, 0, 0, 0, 0, 0, 0, 0}, nebula.PropertyType_GEOGRAPHY)
+	assert.NoError(t, err)
+	kvs = v.GetMVal().GetKvs()
+	assert.InDelta(t, 35.264, kvs["lat"].GetLVal().Values[0].GetFVal(), 1e-3)
+	assert.Equal(t, 90.0, kvs["lat"].GetLVal().Values[1].GetFVal())
+	assert.Equal(t, -180.0, kvs["lng"].GetLVal().Values[0].GetFVal())
+	assert.Equal(t, 180.0, kvs["lng"].GetLVal().Values[1].GetFVal())
+
+	_, err = GetIndexValue([]byte{0xff, 0, 0, 0, 0, 0, 0, 0}, nebula.PropertyType_GEOGRAPHY)
+	assert.Error(t, err)
+}
+
+func TestDuration(t *testing.T) {
+	s := meta.NewSchema()
+	s.Columns = []*meta.ColumnDef{newColumn("d", nebula.PropertyType_DURATION)}
+	buf := make([]byte, 1+16)
+	buf[0] = 0x08
+	common.ByteOrder.PutUint64(buf[1:], 3661)
+	common.ByteOrder.PutUint32(buf[9:], 500)
+	common.ByteOrder.PutUint32(buf[13:], 14)
+
+	row, err := NewRowReader(s, buf, 1).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := row.GetValues()
+	assert.True(t, values[0].IsSetDuVal())
+	assert.Equal(t, "P14MT3661.000500000S", FormatValue(values[0]))
+
+	// list and set are not property types of nebula
+	s.Columns = []*meta.ColumnDef{newColumn("names", 32)}
+	_, err = NewRowReader(s, []byte{0x08, 0, 0, 0, 0, 0, 0, 0, 0}, 1).read()
+	assert.Error(t, err)
+}
+
+type fakeSchema struct {
+	spaces  map[int32]*meta.SpaceItem
+	tags    map[int32][]*meta.TagItem
+	edges   map[int32][]*meta.EdgeItem
+	indexes map[int32][]*meta.IndexItem
+}
+
+func (f *fakeSchema) Update() error { return nil }
+func (f *fakeSchema) ListSpaces() []int32 {
+	ids := make([]int32, 0)
+	for id := range f.spaces {
+		ids = append(ids, id)
+	}
+	return ids
+}
+func (f *fakeSchema) GetSpace(space int32) *meta.SpaceItem     { return f.spaces[space] }
+func (f *fakeSchema) GetTags(space int32) []*meta.TagItem      { return f.tags[space] }
+func (f *fakeSchema) GetEdges(space int32) []*meta.EdgeItem    { return f.edges[space] }
+func (f *fakeSchema) GetIndexes(space int32) []*meta.IndexItem { return f.indexes[space] }
+func (f *fakeSchema) Close() error                             { return nil }
+
+func newTagItem(id int32, version int64, columns ...*meta.ColumnDef) *meta.TagItem {
+	item := meta.NewTagItem()
+	item.TagID = id
+	item.TagName = []byte("person")
+	item.Version = version
+	item.Schema = meta.NewSchema()
+	item.Schema.Columns = columns
+	return item
+}
+
+func TestNullable(t *testing.T) {
+	name := newColumn("name", nebula.PropertyType_STRING)
+	name.Nullable = true
+	age := newColumn("age", nebula.PropertyType_INT64)
+	age.Nullable = true
+	s := meta.NewSchema()
+	s.Columns = []*meta.ColumnDef{name, age}
+
+	// name is null, the string slot is garbage and must not be read.
+	buf := make([]byte, 1+1+8+8)
+	buf[0] = 0x08
+	buf[1] = 0x80
+	common.ByteOrder.PutUint32(buf[2:], 0xffff)
+	common.ByteOrder.PutUint64(buf[10:], 30)
+	row, err := NewRowReader(s, buf, 1).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, row.GetValues()[0].IsSetNVal())
+	assert.Equal(t, "__NULL__", FormatValue(row.GetValues()[0]))
+	assert.Equal(t, "30", FormatValue(row.GetValues()[1]))
+}
+
+func TestMissingColumns(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	// alter tag person add (city string default "sh", score double null, level int)
+	city := newColumn("city", nebula.PropertyType_STRING)
+	city.DefaultValue = []byte{0, 16, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 's', 'h'}
+	score := newColumn("score", nebula.PropertyType_DOUBLE)
+	score.Nullable = true
+	level := newColumn("level", nebula.PropertyType_INT64)
+	level.DefaultValue = []byte{9}
+	schema := &fakeSchema{
+		tags: map[int32][]*meta.TagItem{
+			1: {
+				newTagItem(2, 0, newColumn("age", nebula.PropertyType_INT64)),
+				newTagItem(2, 1, newColumn("age", nebula.PropertyType_INT64), city, score, level),
+			},
+		},
+	}
+	buf := make([]byte, 1+8+8)
+	buf[0] = 0x08
+	common.ByteOrder.PutUint64(buf[1:], 30)
+	common.ByteOrder.PutUint64(buf[9:], 1614902400)
+	data, err := decodeValue("tag", buf, 1, 2, schema, pkg.MissingSchemaFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, [][]byte{[]byte("age"), []byte("city"), []byte("score"), []byte("level")}, data.dataset.ColumnNames)
+	values := data.dataset.Rows[0].GetValues()
+	assert.Equal(t, "30", FormatValue(values[0]))
+	assert.Equal(t, `"sh"`, FormatValue(values[1]))
+	assert.Equal(t, "__NULL__", FormatValue(values[2]))
+	assert.Equal(t, "UNKNOWN_PROP", FormatValue(values[3]))
+	assert.Equal(t, int64(1614902400), data.timestamp)
+}
+
+func TestDefaultValue(t *testing.T) {
+	// the bytes of the constant expressions encoded by nebula, the padding of the structs is 0xcc
+	// time("12:34:56.000789")
+	v, err := decodeDefaultValue([]byte{0, 64, 0, 0, 0, 0, 0, 0, 0, 12, 34, 56, 0xcc, 0x15, 0x03, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &nebula.Time{Hour: 12, Minute: 34, Sec: 56, Microsec: 789}, v.GetTVal())
+	// datetime("2021-03-05T12:34:56.000789")
+	v, err = decodeDefaultValue([]byte{0, 128, 0, 0, 0, 0, 0, 0, 0, 0xe5, 0x07, 3, 5, 12, 34, 56, 0xcc, 0x15, 0x03, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &nebula.DateTime{Year: 2021, Month: 3, Day: 5, Hour: 12, Minute: 34, Sec: 56, Microsec: 789}, v.GetDtVal())
+	// date("2021-03-05")
+	v, err = decodeDefaultValue([]byte{0, 32, 0, 0, 0, 0, 0, 0, 0, 0xe5, 0x07, 3, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &nebula.Date{Year: 2021, Month: 3, Day: 5}, v.GetDVal())
+
+	_, err = decodeDefaultValue([]byte{0, 64, 0, 0, 0, 0, 0, 0, 0, 12, 34, 56, 0xcc, 0x15, 0x03, 0})
+	assert.Error(t, err)
+	_, err = decodeDefaultValue([]byte{0, 128, 0, 0, 0, 0, 0, 0, 0, 0xe5, 0x07, 3, 5, 12, 34, 56, 0xcc})
+	assert.Error(t, err)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	b := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(b, v)
+	return append(buf, b[:n]...)
+}
+
+func TestRowV1(t *testing.T) {
+	columns := make([]*meta.ColumnDef, 0)
+	for i := 0; i < 17; i++ {
+		columns = append(columns, newColumn(fmt.Sprintf("c%d", i), nebula.PropertyType_INT64))
+	}
+	columns = append(columns, newColumn("name", nebula.PropertyType_STRING))
+	schema := &fakeSchema{
+		tags: map[int32][]*meta.TagItem{1: {newTagItem(2, 1, columns...)}},
+	}
+
+	// one byte version, one byte offset
+	buf := []byte{0x20, 0x01}
+	// block offset of the 17th field
+	buf = append(buf, 0)
+	var fields []byte
+	for i := 0; i < 16; i++ {
+		fields = appendUvarint(fields, uint64(i*100))
+	}
+	buf[2] = byte(len(fields))
+	fields = appendUvarint(fields, uint64(math.MaxUint64)) // -1
+	fields = appendUvarint(fields, 3)
+	fields = append(fields, "Tom"...)
+	buf = append(buf, fields...)
+
+	data, err := decodeValue("tag", buf, 1, 2, schema, pkg.MissingSchemaFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := data.dataset.Rows[0].GetValues()
+	assert.Equal(t, int64(1), data.version)
+	assert.Equal(t, int64(0), data.timestamp)
+	assert.Equal(t, "1500", FormatValue(values[15]))
+	assert.Equal(t, "-1", FormatValue(values[16]))
+	assert.Equal(t, `"Tom"`, FormatValue(values[17]))
+
+	// truncated row returns an error instead of panic
+	_, err = decodeValue("tag", buf[:len(buf)-2], 1, 2, schema, pkg.MissingSchemaFail)
+	assert.Error(t, err)
+}
+
+func TestMissingSchema(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	schema := &fakeSchema{
+		tags: map[int32][]*meta.TagItem{
+			1: {
+				newTagItem(2, 0, newColumn("age", nebula.PropertyType_INT64)),
+				newTagItem(2, 3, newColumn("age", nebula.PropertyType_INT64), newColumn("id", nebula.PropertyType_INT64)),
+			},
+		},
+	}
+	// version 2 is missing
+	buf := make([]byte, 2+8+8)
+	buf[0], buf[1] = 0x09, 2
+	common.ByteOrder.PutUint64(buf[2:], 30)
+
+	_, err := decodeValue("tag", buf, 1, 2, schema, pkg.MissingSchemaFail)
+	assert.EqualError(t, err, "cannot get the schema, tag:2, version:2")
+	assert.True(t, skipMissingSchema(err, pkg.MissingSchemaSkip))
+	assert.False(t, skipMissingSchema(err, pkg.MissingSchemaFail))
+
+	data, err := decodeValue("tag", buf, 1, 2, schema, pkg.MissingSchemaNearest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(2), data.version)
+	assert.Equal(t, int64(0), data.schemaVersion)
+	assert.Equal(t, "version:2(schema version:0), age:30, id:UNKNOWN_PROP, timestamp:0", formatRowData(data))
+}
+
+func TestVersionReport(t *testing.T) {
+	schema := &fakeSchema{
+		tags: map[int32][]*meta.TagItem{1: {newTagItem(2, 0, newColumn("age", nebula.PropertyType_INT64))}},
+	}
+	r := &versionReport{opts: &pkg.Option{SpaceID: 1, Limit: 1}, schema: schema, vidLength: 8, counts: make(map[versionCount]int64)}
+	key := func(part byte, t int32, id byte) []byte {
+		return []byte{byte(t), part, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, id, 0, 0, 0}
+	}
+	// the rows of the same version in different parts are counted together
+	for part := byte(1); part <= 3; part++ {
+		assert.NoError(t, r.add(kTag, key(part, kTag, 2), []byte{0x08}))
+		assert.NoError(t, r.add(kTag, key(part, kTag, 2), []byte{0x09, 1}))
+	}
+	assert.NoError(t, r.add(kEdge, key(1, kEdge, 3), []byte{}))
+	assert.Error(t, r.add(kTag, key(1, kTag, 2)[:10], []byte{0x08}))
+
+	// the limit is not applied to the report
+	kvstrings := r.kvstrings()
+	assert.Equal(t, 3, len(kvstrings))
+	assert.Equal(t, "tag:2, version:0", kvstrings[0].Key)
+	assert.Equal(t, "count:3, schema:found", kvstrings[0].Value)
+	assert.Equal(t, "tag:2, version:1", kvstrings[1].Key)
+	assert.Equal(t, "count:3, schema:missing", kvstrings[1].Value)
+	assert.Equal(t, "edge:3, version:-1", kvstrings[2].Key)
+	assert.Equal(t, int64(1), kvstrings[2].Fields[3].Value)
+}
+
+func TestTTL(t *testing.T) {
+	item := newTagItem(2, 0, newColumn("created", nebula.PropertyType_TIMESTAMP))
+	duration := int64(100)
+	item.Schema.SchemaProp = &meta.SchemaProp{TtlCol: []byte("created"), TtlDuration: &duration}
+	schema := &fakeSchema{tags: map[int32][]*meta.TagItem{1: {item}}}
+	buf := make([]byte, 1+8+8)
+	buf[0] = 0x08
+	common.ByteOrder.PutUint64(buf[1:], 1000)
+
+	cases := []struct {
+		now     int64
+		mode    string
+		keep    bool
+		expired bool
+	}{
+		{1100, pkg.TTLInclude, true, false},
+		{1101, pkg.TTLInclude, true, true},
+		{1101, pkg.TTLExclude, false, true},
+		{1100, pkg.TTLExclude, true, false},
+		{1101, pkg.TTLOnly, true, true},
+		{1100, pkg.TTLOnly, false, false},
+	}
+	for _, c := range cases {
+		data, err := decodeValue("tag", buf, 1, 2, schema, pkg.MissingSchemaFail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := &pkg.Option{SpaceID: 1, TTL: c.mode, Now: c.now}
+		assert.Equal(t, c.keep, filterTTL("tag", 2, data, schema, opts))
+		assert.True(t, data.ttl)
+		assert.Equal(t, c.expired, data.expired)
+	}
+	data, _ := decodeValue("tag", buf, 1, 2, schema, pkg.MissingSchemaFail)
+	filterTTL("tag", 2, data, schema, &pkg.Option{SpaceID: 1, Now: 2000})
+	assert.Equal(t, "version:0, created:1000, timestamp:0, expired:true", formatRowData(data))
+}
+
+func newSpaceItem(vidType nebula.PropertyType, vidLength int16, parts int32) *meta.SpaceItem {
+	item := meta.NewSpaceItem()
+	item.Properties = meta.NewSpaceDesc()
+	item.Properties.PartitionNum = parts
+	item.Properties.VidType = &meta.ColumnTypeDef{Type: vidType, TypeLength: vidLength}
+	return item
+}
+
+func TestVertexParser(t *testing.T) {
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 4, 10)},
+	}
+	p := &vertexParser{opts: &pkg.Option{SpaceID: 1}, schema: schema}
+	kvstring, err := p.Parse(&common.KV{Key: []byte{0x07, 3, 0, 0, 'T', 'o', 'm', 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, vid:Tom\x00", kvstring.Key)
+	assert.Equal(t, "", kvstring.Value)
+
+	_, err = p.Parse(&common.KV{Key: []byte{0x07, 3, 0, 0, 'T', 'o', 'm'}})
+	assert.Error(t, err)
+}
+
+func TestEdgeIndexParser(t *testing.T) {
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_INT64, 8, 10)},
+	}
+	age := newColumn("age", nebula.PropertyType_INT64)
+	age.Nullable = true
+	index := &meta.IndexItem{
+		IndexID:  27,
+		SchemaID: &nebula.SchemaID{EdgeType: func() *int32 { i := int32(5); return &i }()},
+		Fields:   []*meta.ColumnDef{age},
+	}
+	p := &indexParser{opts: &pkg.Option{SpaceID: 1}, schema: schema}
+	p.setIndex(index)
+
+	key := []byte{0x02, 3, 0, 0, 27, 0, 0, 0}
+	// age: 30, sign bit flipped
+	key = append(key, 0x80, 0, 0, 0, 0, 0, 0, 30)
+	// nullable bitmap
+	key = append(key, 0, 0)
+	src := make([]byte, 8)
+	binary.LittleEndian.PutUint64(src, 98)
+	key = append(key, src...)
+	// rank: -1, sign bit flipped
+	key = append(key, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	dst := make([]byte, 8)
+	binary.LittleEndian.PutUint64(dst, 99)
+	key = append(key, dst...)
+
+	kvstring, err := p.Parse(&common.KV{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, index:27, age:30, src:98, rank:-1, dst:99", kvstring.Key)
+
+	_, err = p.Parse(&common.KV{Key: key[:len(key)-4]})
+	assert.Error(t, err)
+}
+
+func TestStringIndex(t *testing.T) {
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 4, 10)},
+	}
+	name := newColumn("name", nebula.PropertyType_STRING)
+	name.Type.TypeLength = 6
+	age := newColumn("age", nebula.PropertyType_INT64)
+	index := &meta.IndexItem{
+		IndexID:  28,
+		SchemaID: &nebula.SchemaID{TagID: func() *int32 { i := int32(2); return &i }()},
+		Fields:   []*meta.ColumnDef{name, age},
+	}
+	p := &indexParser{opts: &pkg.Option{SpaceID: 1}, schema: schema}
+	p.setIndex(index)
+	assert.False(t, p.hasNull)
+
+	key := []byte{0x02, 3, 0, 0, 28, 0, 0, 0}
+	key = append(key, 'T', 'o', 'm', 0, 0, 0)
+	key = append(key, 0x80, 0, 0, 0, 0, 0, 0, 30)
+	key = append(key, 'v', '1', 0, 0)
+	kvstring, err := p.Parse(&common.KV{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, index:28, name:\"Tom\x00\x00\x00\",age:30, vid:v1\x00\x00", kvstring.Key)
+
+	// nullable index has a nullable bitmap before the vid
+	age.Nullable = true
+	p.setIndex(index)
+	assert.True(t, p.hasNull)
+	key = append(key[:len(key)-4], 0x00, 0x40, 'v', '1', 0, 0)
+	kvstring, err = p.Parse(&common.KV{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, index:28, name:\"Tom\x00\x00\x00\",age:__null__, vid:v1\x00\x00", kvstring.Key)
+
+	// the length of string must be declared
+	name.Type.TypeLength = 0
+	_, err = p.Parse(&common.KV{Key: key})
+	assert.Error(t, err)
+}
+
+func TestOperationParser(t *testing.T) {
+	name := newColumn("name", nebula.PropertyType_FIXED_STRING)
+	name.Type.TypeLength = 4
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 4, 10)},
+		indexes: map[int32][]*meta.IndexItem{1: {{
+			IndexID:  29,
+			SchemaID: &nebula.SchemaID{TagID: func() *int32 { i := int32(2); return &i }()},
+			Fields:   []*meta.ColumnDef{name},
+		}}},
+	}
+	p := &operationParser{opts: &pkg.Option{SpaceID: 1}, schema: schema}
+
+	key := []byte{0x05, 3, 0, 0}
+	ts := make([]byte, 8)
+	binary.LittleEndian.PutUint64(ts, 1672531200000000)
+	key = append(key, ts...)
+	key = append(key, 0x01, 0, 0, 0)
+	key = append(key, 0x03, 3, 0, 0, 29, 0, 0, 0, 'T', 'o', 'm', 0, 'v', '1', 0, 0)
+	kvstring, err := p.Parse(&common.KV{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, operation:modify, timestamp:1672531200000000, part:3, index:29, name:\"Tom\x00\", vid:v1\x00\x00", kvstring.Key)
+
+	key[12] = 0x02
+	kvstring, err = p.Parse(&common.KV{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, operation:delete, timestamp:1672531200000000, part:3, index:29, name:\"Tom\x00\", vid:v1\x00\x00", kvstring.Key)
+
+	// unknown index
+	key[20] = 30
+	_, err = p.Parse(&common.KV{Key: key})
+	assert.Error(t, err)
+}
+
+func TestSystemParser(t *testing.T) {
+	p := &systemParser{opts: &pkg.Option{}}
+	value := make([]byte, 16)
+	binary.LittleEndian.PutUint64(value, 1024)
+	binary.LittleEndian.PutUint64(value[8:], 3)
+	kvstring, err := p.Parse(&common.KV{Key: []byte{0x04, 5, 0, 0, 0x01, 0, 0, 0}, Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:5, type:commit", kvstring.Key)
+	assert.Equal(t, "log id:1024, term:3", kvstring.Value)
+
+	kvstring, err = p.Parse(&common.KV{Key: []byte{0x04, 5, 0, 0, 0x02, 0, 0, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:5, type:part", kvstring.Key)
+
+	kvstring, err = p.Parse(&common.KV{Key: []byte{0x04, 5, 0, 0, 0x03, 0, 0, 0}, Value: []byte{0x01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:5, type:balance", kvstring.Key)
+	assert.Equal(t, "state:01", kvstring.Value)
+
+	_, err = p.Parse(&common.KV{Key: []byte{0x04, 5, 0, 0, 0x01, 0, 0, 0}, Value: value[:8]})
+	assert.Error(t, err)
+}
+
+func TestKVParser(t *testing.T) {
+	kv := &common.KV{Key: []byte{0x06, 2, 0, 0, 'k', '1'}, Value: []byte("v\x001")}
+	p := &kvParser{opts: &pkg.Option{Encoding: pkg.EncodingText}}
+	kvstring, err := p.Parse(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:2, key:k1", kvstring.Key)
+	assert.Equal(t, "v\x001", kvstring.Value)
+
+	p.opts.Encoding = pkg.EncodingHex
+	kvstring, err = p.Parse(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:2, key:6b31", kvstring.Key)
+	assert.Equal(t, "760031", kvstring.Value)
+
+	p.opts.Encoding = pkg.EncodingBase64
+	kvstring, err = p.Parse(kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:2, key:azE=", kvstring.Key)
+	assert.Equal(t, "dgAx", kvstring.Value)
+
+	p.opts.Encoding = "json"
+	_, err = p.Parse(kv)
+	assert.Error(t, err)
+}
+
+func TestKeyDecoder(t *testing.T) {
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 4, 10)},
+	}
+	d := newKeyDecoder(&pkg.Option{SpaceID: 1, WithTags: true}, schema)
+	assert.False(t, d.opts.WithTags)
+
+	// a removed tag has no value
+	kvstring, err := d.Decode([]byte{0x01, 3, 0, 0, 'T', 'o', 'm', 0, 2, 0, 0, 0}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, vid:Tom\x00, tag:2", kvstring.Key)
+
+	kvstring, err = d.Decode([]byte{0x06, 3, 0, 0, 'k'}, []byte("v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, key:k", kvstring.Key)
+	assert.Equal(t, "v", kvstring.Value)
+
+	kvstring, err = d.Decode([]byte{0x0a, 3, 0, 0}, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "type:10, key:0a030000", kvstring.Key)
+	assert.Equal(t, "01", kvstring.Value)
+
+	_, err = d.Decode([]byte{0x01}, nil)
+	assert.Error(t, err)
+}
+
+func TestEdgeKey(t *testing.T) {
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 2, 10)},
+	}
+	p := &edgeParser{opts: &pkg.Option{SpaceID: 1}, schema: schema}
+	edgeKey := func(t byte, version byte) []byte {
+		key := []byte{t, 3, 0, 0, 'a', 0, 5, 0, 0, 0}
+		key = append(key, 0x80, 0, 0, 0, 0, 0, 0, 1)
+		return append(key, 'b', 0, version)
+	}
+
+	kvstring, edgeType, err := p.parseKey(edgeKey(0x02, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(5), edgeType)
+	assert.Equal(t, "part:3, src:a\x00, edge:5, dst:b\x00, rank:1", kvstring.Key)
+
+	kvstring, _, err = p.parseKey(edgeKey(0x02, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, src:a\x00, edge:5, dst:b\x00, rank:1, lock", kvstring.Key)
+	assert.Equal(t, []common.Field{
+		{Name: "part", Value: int32(3)},
+		{Name: "src", Value: "a\x00"},
+		{Name: "edge", Value: int32(5)},
+		{Name: "dst", Value: "b\x00"},
+		{Name: "rank", Value: int64(1)},
+		{Name: "state", Value: "lock"},
+	}, kvstring.Fields)
+
+	kvstring, err = p.Parse(&common.KV{Key: edgeKey(0x08, 1), Value: []byte{1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, src:a\x00, edge:5, dst:b\x00, rank:1, prime", kvstring.Key)
+	assert.Equal(t, "length:3", kvstring.Value)
+
+	kvstring, _, err = p.parseKey(edgeKey(0x09, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "part:3, src:a\x00, edge:5, dst:b\x00, rank:1, double prime", kvstring.Key)
+
+	_, _, err = p.parseKey(edgeKey(0x02, 1)[:20])
+	assert.Error(t, err)
+}
+
+func TestPrefixAllParts(t *testing.T) {
+	space := newSpaceItem(nebula.PropertyType_INT64, 8, 3)
+	parts := make([]int32, 0)
+	iterate := func(part int32, limit int, fn func(*common.KV) error) error {
+		parts = append(parts, part)
+		for i := 0; i < 2 && i < limit; i++ {
+			if err := fn(common.NewKV([]byte{byte(part)}, nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	prefix := func(limit int) ([]*common.KV, error) {
+		return common.Collect(func(fn func(*common.KV) error) error {
+			return prefixAllParts(space, limit, iterate, fn)
+		})
+	}
+	kvs, err := prefix(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int32{1, 2, 3}, parts)
+	assert.Equal(t, 5, len(kvs))
+	assert.Equal(t, []byte{3}, kvs[4].Key)
+
+	// stops when reaching the limit
+	parts = parts[:0]
+	kvs, err = prefix(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int32{1}, parts)
+	assert.Equal(t, 2, len(kvs))
+
+	// stops when fn returns an error
+	parts = parts[:0]
+	err = prefixAllParts(space, 5, iterate, func(kv *common.KV) error {
+		return fmt.Errorf("stop")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []int32{1}, parts)
+
+	opts := &pkg.Option{SpaceID: 1, PartID: -1, AllParts: true}
+	assert.NoError(t, verifyAllParts(opts))
+	opts.VID = "1"
+	assert.Error(t, verifyAllParts(opts))
+	opts.VID, opts.PartID = "", 1
+	assert.Error(t, verifyAllParts(opts))
+	opts.AllParts = false
+	assert.NoError(t, verifyAllParts(opts))
+}
+
+func TestRowFields(t *testing.T) {
+	name, null := []byte("Tom"), newNullValue(nebula.NullType___NULL__)
+	nan := math.NaN()
+	data := &rowData{
+		version:       2,
+		schemaVersion: 1,
+		timestamp:     100,
+		dataset: &nebula.DataSet{
+			ColumnNames: [][]byte{[]byte("vid"), []byte("name"), []byte("age"), []byte("score")},
+			Rows: []*nebula.Row{{Values: []*nebula.Value{
+				{SVal: name}, {SVal: name}, null, {FVal: &nan},
+			}}},
+		},
+	}
+	kvstring := &common.KVString{}
+	kvstring.AddField("vid", "v1")
+	addRowFields(kvstring, data)
+	assert.Equal(t, []common.Field{
+		{Name: "vid", Value: "v1"},
+		{Name: "version", Value: int64(2)},
+		{Name: "schema_version", Value: int64(1)},
+		{Name: "prop.vid", Value: "Tom"},
+		{Name: "name", Value: "Tom"},
+		{Name: "age", Value: nil},
+		{Name: "score", Value: "NaN"},
+		{Name: "timestamp", Value: int64(100)},
+	}, kvstring.Fields)
+}
+
+func TestRow(t *testing.T) {
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 4, 10)},
+		tags: map[int32][]*meta.TagItem{
+			1: {newTagItem(2, 0, newColumn("age", nebula.PropertyType_INT64))},
+		},
+	}
+	p := &tagParser{opts: &pkg.Option{SpaceID: 1, Rows: true}, schema: schema}
+	value := make([]byte, 1+8+8)
+	value[0] = 0x08
+	common.ByteOrder.PutUint64(value[1:], 30)
+	kvstring, err := p.Parse(&common.KV{Key: []byte{0x01, 3, 0, 0, 'T', 'o', 'm', 0, 2, 0, 0, 0}, Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	age := int64(30)
+	assert.Equal(t, &common.Row{
+		Name: "person", Vid: &nebula.Value{SVal: []byte("Tom")}, Columns: []string{"age"},
+		Types: []nebula.PropertyType{nebula.PropertyType_INT64}, Values: []*nebula.Value{{IVal: &age}},
+	}, kvstring.Row)
+
+	// the fixed string is cut at the first NUL like nebula, the string is kept
+	values := RowValues(&common.Row{
+		Types:  []nebula.PropertyType{nebula.PropertyType_FIXED_STRING, nebula.PropertyType_STRING, nebula.PropertyType_FIXED_STRING},
+		Values: []*nebula.Value{{SVal: []byte("ab\x00\x00")}, {SVal: []byte("ab\x00")}, newNullValue(nebula.NullType___NULL__)},
+	})
+	assert.Equal(t, []*nebula.Value{{SVal: []byte("ab")}, {SVal: []byte("ab\x00")}, newNullValue(nebula.NullType___NULL__)}, values)
+
+	// the reverse edge and the lock are not exported
+	e := &edgeParser{opts: &pkg.Option{SpaceID: 1, Rows: true}, schema: schema}
+	edgeKey := func(edgeType int32, version byte) []byte {
+		key := []byte{0x02, 3, 0, 0, 'a', 0, 0, 0, 0, 0, 0, 0}
+		common.ByteOrder.PutUint32(key[8:], uint32(edgeType))
+		key = append(key, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+		return append(key, 'b', '"', 0, 0, version)
+	}
+	kvstring, _, err = e.parseKey(edgeKey(5, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &common.Row{Edge: true, Vid: &nebula.Value{SVal: []byte("a")}, Dst: &nebula.Value{SVal: []byte(`b"`)}, Rank: -1}, kvstring.Row)
+	kvstring, _, err = e.parseKey(edgeKey(5, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, kvstring.Row)
+	kvstring, _, err = e.parseKey(edgeKey(-5, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, kvstring.Row)
+
+	// the int vid
+	schema.spaces[1] = newSpaceItem(nebula.PropertyType_INT64, 8, 10)
+	v, err := vidValue("-7", 1, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(-7), v.GetIVal())
+}
+
+func TestRowWriter(t *testing.T) {
+	age := newColumn("age", nebula.PropertyType_INT64)
+	age.Nullable = true
+	schema := &fakeSchema{
+		spaces: map[int32]*meta.SpaceItem{1: newSpaceItem(nebula.PropertyType_FIXED_STRING, 4, 10)},
+		tags: map[int32][]*meta.TagItem{
+			1: {newTagItem(2, 3, newColumn("name", nebula.PropertyType_STRING), age)},
+		},
+	}
+	name, null := []byte("Tom"), newNullValue(nebula.NullType___NULL__)
+	value, err := NewRowWriter(schema.tags[1][0].Schema, 3).Write(&nebula.Row{Values: []*nebula.Value{{SVal: name}, null}}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vid, part, err := EncodeVid("Tom", 1, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte("Tom\x00"), vid)
+
+	p := &tagParser{opts: &pkg.Option{SpaceID: 1}, schema: schema}
+	kvstring, err := p.Parse(&common.KV{Key: TagKey(part, vid, 2), Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fmt.Sprintf("part:%d, vid:Tom\x00, tag:2", part), kvstring.Key)
+	assert.Equal(t, `version:3, name:"Tom", age:__NULL__, timestamp:100`, kvstring.Value)
+
+	_, err = NewRowWriter(schema.tags[1][0].Schema, 3).Write(&nebula.Row{Values: []*nebula.Value{null, null}}, 100)
+	assert.Error(t, err)
+}
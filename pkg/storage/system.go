@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// system key types
+const (
+	systemCommit  uint32 = 0x01
+	systemPart    uint32 = 0x02
+	systemBalance uint32 = 0x03
+)
+
+// systemParser
+// key: (type + part) + system key type(4bit)
+// value: committed log id(8bit) + term(8bit) for the commit key, [] for the part key,
+// the balance state of the part for the balance key, it's shown in hex.
+type systemParser struct {
+	opts   *pkg.Option
+	engine *common.Engine
+}
+
+func (p *systemParser) New(engine *common.Engine, opts *pkg.Option) pkg.Parser {
+	return &systemParser{
+		opts:   opts,
+		engine: engine,
+	}
+}
+
+func (p *systemParser) Parse(kv *common.KV) (*common.KVString, error) {
+	var (
+		kvstring = &common.KVString{}
+		partID   int32
+		keyType  uint32
+	)
+	if len(kv.Key) != common.Sizeof(partID)+common.Sizeof(keyType) {
+		return nil, fmt.Errorf("invalid system key, length is %d", len(kv.Key))
+	}
+	pt, t := kv.Key[:common.Sizeof(partID)], kv.Key[common.Sizeof(partID):]
+	if err := common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	partID >>= 8
+	if err := common.ConvertBytesToInt(&keyType, &t, common.ByteOrder); err != nil {
+		return nil, err
+	}
+
+	switch keyType {
+	case systemCommit:
+		var logID, term int64
+		if len(kv.Value) < common.Sizeof(logID)+common.Sizeof(term) {
+			return nil, fmt.Errorf("invalid commit value, length is %d", len(kv.Value))
+		}
+		l, tm := kv.Value[:common.Sizeof(logID)], kv.Value[common.Sizeof(logID):common.Sizeof(logID)+common.Sizeof(term)]
+		if err := common.ConvertBytesToInt(&logID, &l, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		if err := common.ConvertBytesToInt(&term, &tm, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		kvstring.Key = fmt.Sprintf("part:%d, type:commit", partID)
+		kvstring.Value = fmt.Sprintf("log id:%d, term:%d", logID, term)
+		kvstring.AddField("part", partID)
+		kvstring.AddField("type", "commit")
+		kvstring.AddField("log_id", logID)
+		kvstring.AddField("term", term)
+	case systemPart:
+		kvstring.Key = fmt.Sprintf("part:%d, type:part", partID)
+		kvstring.AddField("part", partID)
+		kvstring.AddField("type", "part")
+	case systemBalance:
+		state := hex.EncodeToString(kv.Value)
+		kvstring.Key = fmt.Sprintf("part:%d, type:balance", partID)
+		kvstring.Value = fmt.Sprintf("state:%s", state)
+		kvstring.AddField("part", partID)
+		kvstring.AddField("type", "balance")
+		kvstring.AddField("state", state)
+	default:
+		kvstring.Key = fmt.Sprintf("part:%d, type:%d", partID, keyType)
+		kvstring.Value = fmt.Sprintf("length:%d", len(kv.Value))
+		kvstring.AddField("part", partID)
+		kvstring.AddField("type", keyType)
+		kvstring.AddField("length", len(kv.Value))
+	}
+	return kvstring, nil
+}
+
+func (p *systemParser) Iterate(fn func(*common.KV) error) error {
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
+	}
+	// the low byte is the key type, in little endian the first byte matches the system keys of all parts
+	s := []byte{byte(kSystem)}
+	if p.opts.PartID != -1 {
+		item := p.opts.PartID<<8 | kSystem
+		s = nil
+		if err := common.ConvertIntToBytes(&item, &s, common.ByteOrder); err != nil {
+			return err
+		}
+	}
+	return p.engine.PrefixEach(s, p.opts.Limit, nil, nil, fn)
+}
+
+func (p *systemParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
+}
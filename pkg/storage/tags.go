@@ -3,7 +3,6 @@ package storage
 import (
 	"bytes"
 	"fmt"
-	"strings"
 
 	"github.com/harrischu/nebula-dump/pkg"
 	"github.com/harrischu/nebula-dump/pkg/common"
@@ -28,6 +27,34 @@ func (p *tagParser) New(engine *common.Engine, opts *pkg.Option) pkg.Parser {
 }
 
 func (p *tagParser) Parse(kv *common.KV) (*common.KVString, error) {
+	kvstring, tagID, err := p.parseKey(kv.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	rowData, err := decodeValue("tag", kv.Value, p.opts.SpaceID, tagID, p.schema, p.opts.MissingSchema)
+	if err != nil {
+		if skipMissingSchema(err, p.opts.MissingSchema) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !filterTTL("tag", tagID, rowData, p.schema, p.opts) {
+		return nil, nil
+	}
+	kvstring.Value = formatRowData(rowData)
+	addRowFields(kvstring, rowData)
+	if kvstring.Row != nil {
+		if err := setRowValues(kvstring.Row, "tag", p.opts.SpaceID, tagID, rowData, p.schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return kvstring, nil
+}
+
+// parseKey decodes the tag key, and returns the tag id.
+func (p *tagParser) parseKey(key []byte) (*common.KVString, int32, error) {
 	var (
 		kvstring = &common.KVString{}
 		partID   int32
@@ -37,59 +64,53 @@ func (p *tagParser) Parse(kv *common.KV) (*common.KVString, error) {
 	)
 	vidLength := p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().TypeLength
 
-	pt, v, t := kv.Key[:common.Sizeof(partID)], kv.Key[common.Sizeof(partID):common.Sizeof(partID)+int(vidLength)], kv.Key[common.Sizeof(partID)+int(vidLength):]
+	pt, v, t := key[:common.Sizeof(partID)], key[common.Sizeof(partID):common.Sizeof(partID)+int(vidLength)], key[common.Sizeof(partID)+int(vidLength):]
 	if err = common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	partID = partID | tagID
 	partID >>= 8
 	if vid, err = getVidString(v, p.opts.SpaceID, p.schema); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if err = common.ConvertBytesToInt(&tagID, &t, common.ByteOrder); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	kvstring.Key = fmt.Sprintf("part:%d, vid:%s, tag:%d", partID, vid, tagID)
-
-	rowData, err := decodeValue("tag", kv.Value, p.opts.SpaceID, tagID, p.schema)
-	if err != nil {
-		return nil, err
-	}
-	valuse := make([]string, 0)
-	valuse = append(valuse, fmt.Sprintf("version:%d", rowData.version))
-	row := rowData.dataset.Rows[0]
-
-	for i := 0; i < len(rowData.dataset.ColumnNames); i++ {
-		valuse = append(valuse, fmt.Sprintf("%s:%s", rowData.dataset.ColumnNames[i], formatValue(row.GetValues()[i])))
+	kvstring.AddField("part", partID)
+	kvstring.AddField("vid", vid)
+	kvstring.AddField("tag", tagID)
+	if p.opts.Rows {
+		if kvstring.Row, err = newVertexRow(vid, p.opts.SpaceID, p.schema); err != nil {
+			return nil, 0, err
+		}
 	}
-	valuse = append(valuse, fmt.Sprintf("timestamp:%d", rowData.timestamp))
-	kvstring.Value = strings.Join(valuse, ", ")
-
-	return kvstring, nil
+	return kvstring, tagID, nil
 }
 
-func (p *tagParser) Prefix() ([]*common.KV, error) {
-	s := make([]byte, 0)
+func (p *tagParser) Iterate(fn func(*common.KV) error) error {
 	var part int32
 	if err := verifyOption(p.opts); err != nil {
-		return nil, err
+		return err
 	}
-
-	if p.opts.PartID == -1 && p.opts.VID == "" {
-		return nil, fmt.Errorf("must provide a valid part or a valid VID")
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
 	}
-	schema, err := schemacache.NewFileCache(p.opts.MetaAddres)
-	if err != nil {
-		return nil, err
+	if !p.opts.AllParts && p.opts.PartID == -1 && p.opts.VID == "" {
+		return fmt.Errorf("must provide a valid part or a valid VID")
 	}
-	if err := schema.Update(); err != nil {
-		return nil, err
+	schema, err := loadSchema(p.schema, p.opts)
+	if err != nil {
+		return err
 	}
 
 	p.schema = schema
 	space := schema.GetSpace(p.opts.SpaceID)
 	if space == nil {
-		return nil, fmt.Errorf("cannot find the space")
+		return fmt.Errorf("cannot find the space")
+	}
+	if p.opts.AllParts {
+		return prefixAllParts(space, p.opts.Limit, p.iteratePart, fn)
 	}
 
 	if p.opts.VID == "" {
@@ -97,26 +118,31 @@ func (p *tagParser) Prefix() ([]*common.KV, error) {
 	} else {
 		bs, err := getVidByte(p.opts.VID, p.opts.SpaceID, p.schema)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		id, err := common.GetPartID(bs, space.GetProperties().GetPartitionNum())
 		if err != nil {
-			return nil, err
+			return err
 		}
 		part = id
 	}
+	return p.iteratePart(part, p.opts.Limit, fn)
+}
 
+// iteratePart passes at most limit tag kvs of the part to fn.
+func (p *tagParser) iteratePart(part int32, limit int, fn func(*common.KV) error) error {
+	s := make([]byte, 0)
 	item := part<<8 | kTag
 	var partData []byte
 	if err := common.ConvertIntToBytes(&item, &partData, common.ByteOrder); err != nil {
-		return nil, err
+		return err
 	}
 	s = append(s, partData...)
 	// append vid
 	if p.opts.VID != "" {
-		vidBy, err := getVidByte(p.opts.VID, p.opts.SpaceID, schema)
+		vidBy, err := getVidByte(p.opts.VID, p.opts.SpaceID, p.schema)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		s = append(s, vidBy...)
 	}
@@ -124,9 +150,9 @@ func (p *tagParser) Prefix() ([]*common.KV, error) {
 	if p.opts.TagID != -1 {
 		var tag []byte
 		if err := common.ConvertIntToBytes(&p.opts.TagID, &tag, common.ByteOrder); err != nil {
-			return nil, err
+			return err
 		}
-		fn := func(key []byte) bool {
+		cond := func(key []byte) bool {
 			l := len(tag)
 			n := len(key)
 			if bytes.Compare(key[n-l:], tag) == 0 {
@@ -134,7 +160,11 @@ func (p *tagParser) Prefix() ([]*common.KV, error) {
 			}
 			return false
 		}
-		return p.engine.PrefixWithCondition(s, p.opts.Limit, fn, nil)
+		return p.engine.PrefixEach(s, limit, cond, nil, fn)
 	}
-	return p.engine.Prefix(s, p.opts.Limit)
+	return p.engine.PrefixEach(s, limit, nil, nil, fn)
+}
+
+func (p *tagParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
 }
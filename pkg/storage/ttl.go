@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+)
+
+// checkTTL marks the row expired follow nebula, the row is expired when
+// now > value of ttl column + ttl duration. ttl of the latest schema takes effect.
+func checkTTL(t string, spaceID, id int32, d *rowData, schema schemacache.Schemacache, now int64) {
+	latest := getLatestSchema(t, spaceID, id, schema)
+	if latest == nil || !latest.IsSetSchemaProp() {
+		return
+	}
+	prop := latest.GetSchemaProp()
+	col := string(prop.GetTtlCol())
+	if col == "" || prop.GetTtlDuration() <= 0 {
+		return
+	}
+	d.ttl = true
+	for i, name := range d.dataset.ColumnNames {
+		if string(name) != col {
+			continue
+		}
+		v := d.dataset.Rows[0].GetValues()[i]
+		// null or other types never expire
+		if v.IsSetIVal() && now > v.GetIVal()+prop.GetTtlDuration() {
+			d.expired = true
+		}
+		return
+	}
+}
+
+// filterTTL checks the row by the ttl mode, returns false if the row should be ignored.
+func filterTTL(t string, id int32, d *rowData, schema schemacache.Schemacache, opts *pkg.Option) bool {
+	now := opts.Now
+	if now == 0 {
+		now = time.Now().Unix()
+	}
+	checkTTL(t, opts.SpaceID, id, d, schema, now)
+	switch opts.TTL {
+	case pkg.TTLExclude:
+		return !d.expired
+	case pkg.TTLOnly:
+		return d.expired
+	default:
+		return true
+	}
+}
+
+func verifyTTLOption(opt *pkg.Option) error {
+	switch opt.TTL {
+	case "", pkg.TTLInclude, pkg.TTLExclude, pkg.TTLOnly:
+		return nil
+	default:
+		return fmt.Errorf("invalid ttl mode %s", opt.TTL)
+	}
+}
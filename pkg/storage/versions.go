@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+)
+
+type versionCount struct {
+	keyType int32
+	id      int32
+	version int64
+}
+
+// versionReport counts the tag and edge rows by the schema versions.
+type versionReport struct {
+	opts      *pkg.Option
+	schema    schemacache.Schemacache
+	vidLength int
+	counts    map[versionCount]int64
+}
+
+// VersionReport reports the row count of every schema version of tags and edges,
+// in all parts of the space, or in the part of --part or --vid.
+// a row is reported for each version, and whether its schema is found in meta,
+// --limit is not applied, the report is small and every missing version matters.
+func VersionReport(engine *common.Engine, opts *pkg.Option) ([]*common.KVString, error) {
+	var parts []int32
+	if err := verifyOption(opts); err != nil {
+		return nil, err
+	}
+	if err := verifyAllParts(opts); err != nil {
+		return nil, err
+	}
+	schema, err := NewSchemaCache(opts)
+	if err != nil {
+		return nil, err
+	}
+	space := schema.GetSpace(opts.SpaceID)
+	if space == nil {
+		return nil, fmt.Errorf("cannot find the space")
+	}
+
+	switch {
+	case opts.PartID != -1:
+		parts = []int32{opts.PartID}
+	case opts.VID != "":
+		bs, err := getVidByte(opts.VID, opts.SpaceID, schema)
+		if err != nil {
+			return nil, err
+		}
+		id, err := common.GetPartID(bs, space.GetProperties().GetPartitionNum())
+		if err != nil {
+			return nil, err
+		}
+		parts = []int32{id}
+	default:
+		for part := int32(1); part <= space.GetProperties().GetPartitionNum(); part++ {
+			parts = append(parts, part)
+		}
+	}
+
+	r := &versionReport{
+		opts:      opts,
+		schema:    schema,
+		vidLength: int(space.GetProperties().GetVidType().GetTypeLength()),
+		counts:    make(map[versionCount]int64),
+	}
+	for _, part := range parts {
+		if err := r.scan(engine, part); err != nil {
+			return nil, fmt.Errorf("part:%d, err: %w", part, err)
+		}
+	}
+	return r.kvstrings(), nil
+}
+
+// scan counts the tag and edge rows of the part.
+func (r *versionReport) scan(engine *common.Engine, part int32) error {
+	var scanErr error
+	for _, keyType := range []int32{kTag, kEdge} {
+		item := part<<8 | keyType
+		var prefix []byte
+		if err := common.ConvertIntToBytes(&item, &prefix, common.ByteOrder); err != nil {
+			return err
+		}
+		t := keyType
+		err := engine.Scan(prefix, func(key, value []byte) bool {
+			if err := r.add(t, key, value); err != nil {
+				scanErr = err
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if scanErr != nil {
+			return scanErr
+		}
+	}
+	return nil
+}
+
+// add counts a tag or edge row, the version is -1 if it cannot be decoded.
+func (r *versionReport) add(keyType int32, key, value []byte) error {
+	var id int32
+	// tag id or edge type follows the src vid
+	if len(key) < 4+r.vidLength+4 {
+		return fmt.Errorf("invalid key %v", key)
+	}
+	b := key[4+r.vidLength : 4+r.vidLength+4]
+	if err := common.ConvertBytesToInt(&id, &b, common.ByteOrder); err != nil {
+		return err
+	}
+	version, _, _, err := getRowVersion(value)
+	if err != nil {
+		common.Logger.Debugf("cannot decode the version, key is %v, err: %v", key, err)
+		version = -1
+	}
+	r.counts[versionCount{keyType, id, version}]++
+	return nil
+}
+
+// kvstrings returns the counts sorted by type, id and version.
+func (r *versionReport) kvstrings() []*common.KVString {
+	keys := make([]versionCount, 0, len(r.counts))
+	for k := range r.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].keyType != keys[j].keyType {
+			return keys[i].keyType < keys[j].keyType
+		}
+		if keys[i].id != keys[j].id {
+			return keys[i].id < keys[j].id
+		}
+		return keys[i].version < keys[j].version
+	})
+	kvstrings := make([]*common.KVString, 0, len(keys))
+	for _, k := range keys {
+		name := "tag"
+		if k.keyType == kEdge {
+			name = "edge"
+		}
+		schemaID := k.id
+		if schemaID < 0 {
+			schemaID = -schemaID
+		}
+		s, _ := getSchema(name, r.opts.SpaceID, schemaID, k.version, r.schema)
+		found := "found"
+		if s == nil {
+			found = "missing"
+		}
+		count := r.counts[k]
+		kvstring := &common.KVString{
+			Key:   fmt.Sprintf("%s:%d, version:%d", name, k.id, k.version),
+			Value: fmt.Sprintf("count:%d, schema:%s", count, found),
+		}
+		kvstring.AddField("type", name)
+		kvstring.AddField("id", k.id)
+		kvstring.AddField("version", k.version)
+		kvstring.AddField("count", count)
+		kvstring.AddField("schema", found)
+		kvstrings = append(kvstrings, kvstring)
+	}
+	return kvstrings
+}
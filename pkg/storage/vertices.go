@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harrischu/nebula-dump/pkg"
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/harrischu/nebula-dump/pkg/schemacache"
+)
+
+// vertexParser
+// key: (type + part) + vid
+// value: []
+type vertexParser struct {
+	opts   *pkg.Option
+	engine *common.Engine
+	schema schemacache.Schemacache
+}
+
+func (p *vertexParser) New(engine *common.Engine, opts *pkg.Option) pkg.Parser {
+	return &vertexParser{
+		opts:   opts,
+		engine: engine,
+	}
+}
+
+func (p *vertexParser) Parse(kv *common.KV) (*common.KVString, error) {
+	var (
+		kvstring = &common.KVString{}
+		partID   int32
+		vid      string
+		err      error
+	)
+	vidLength := p.schema.GetSpace(p.opts.SpaceID).GetProperties().GetVidType().TypeLength
+	if len(kv.Key) != common.Sizeof(partID)+int(vidLength) {
+		return nil, fmt.Errorf("invalid vertex key, length is %d", len(kv.Key))
+	}
+	pt, v := kv.Key[:common.Sizeof(partID)], kv.Key[common.Sizeof(partID):]
+	if err = common.ConvertBytesToInt(&partID, &pt, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	partID >>= 8
+	if vid, err = getVidString(v, p.opts.SpaceID, p.schema); err != nil {
+		return nil, err
+	}
+	kvstring.Key = fmt.Sprintf("part:%d, vid:%s", partID, vid)
+	kvstring.AddField("part", partID)
+	kvstring.AddField("vid", vid)
+
+	if p.opts.WithTags {
+		tags, err := p.getTags(partID, v)
+		if err != nil {
+			return nil, err
+		}
+		kvstring.Value = strings.Join(tags, "; ")
+		kvstring.AddField("tags", kvstring.Value)
+	}
+	return kvstring, nil
+}
+
+// getTags decodes all tag rows of the vertex.
+func (p *vertexParser) getTags(part int32, vid []byte) ([]string, error) {
+	var (
+		prefix  []byte
+		scanErr error
+	)
+	item := part<<8 | kTag
+	if err := common.ConvertIntToBytes(&item, &prefix, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	prefix = append(prefix, vid...)
+	tags := make([]string, 0)
+	err := p.engine.Scan(prefix, func(key, value []byte) bool {
+		var tagID int32
+		// only the tag id follows the vid
+		if len(key) != len(prefix)+common.Sizeof(tagID) {
+			return true
+		}
+		t := key[len(prefix):]
+		if err := common.ConvertBytesToInt(&tagID, &t, common.ByteOrder); err != nil {
+			scanErr = err
+			return false
+		}
+		rowData, err := decodeValue("tag", value, p.opts.SpaceID, tagID, p.schema, p.opts.MissingSchema)
+		if err != nil {
+			if skipMissingSchema(err, p.opts.MissingSchema) {
+				return true
+			}
+			scanErr = fmt.Errorf("tag:%d, err: %w", tagID, err)
+			return false
+		}
+		if !filterTTL("tag", tagID, rowData, p.schema, p.opts) {
+			return true
+		}
+		tags = append(tags, fmt.Sprintf("tag:%d{%s}", tagID, formatRowData(rowData)))
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return tags, nil
+}
+
+func (p *vertexParser) Iterate(fn func(*common.KV) error) error {
+	var part int32
+	if err := verifyOption(p.opts); err != nil {
+		return err
+	}
+
+	if err := verifyAllParts(p.opts); err != nil {
+		return err
+	}
+	if !p.opts.AllParts && p.opts.PartID == -1 && p.opts.VID == "" {
+		return fmt.Errorf("must provide a valid part or a valid VID")
+	}
+	schema, err := NewSchemaCache(p.opts)
+	if err != nil {
+		return err
+	}
+
+	p.schema = schema
+	space := schema.GetSpace(p.opts.SpaceID)
+	if space == nil {
+		return fmt.Errorf("cannot find the space")
+	}
+	if p.opts.AllParts {
+		return prefixAllParts(space, p.opts.Limit, p.iteratePart, fn)
+	}
+
+	if p.opts.VID == "" {
+		part = p.opts.PartID
+	} else {
+		bs, err := getVidByte(p.opts.VID, p.opts.SpaceID, p.schema)
+		if err != nil {
+			return err
+		}
+		id, err := common.GetPartID(bs, space.GetProperties().GetPartitionNum())
+		if err != nil {
+			return err
+		}
+		part = id
+	}
+	return p.iteratePart(part, p.opts.Limit, fn)
+}
+
+// iteratePart passes at most limit vertex kvs of the part to fn.
+func (p *vertexParser) iteratePart(part int32, limit int, fn func(*common.KV) error) error {
+	s := make([]byte, 0)
+	item := part<<8 | kVertex
+	var partData []byte
+	if err := common.ConvertIntToBytes(&item, &partData, common.ByteOrder); err != nil {
+		return err
+	}
+	s = append(s, partData...)
+	// append vid
+	if p.opts.VID != "" {
+		vidBy, err := getVidByte(p.opts.VID, p.opts.SpaceID, p.schema)
+		if err != nil {
+			return err
+		}
+		s = append(s, vidBy...)
+	}
+	return p.engine.PrefixEach(s, limit, nil, nil, fn)
+}
+
+func (p *vertexParser) Prefix() ([]*common.KV, error) {
+	return common.Collect(p.Iterate)
+}
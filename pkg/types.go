@@ -16,10 +16,36 @@ const (
 )
 
 const (
-	StorageKeyTags    StorageKeyType = "tags"
-	StorageKeyEdges                  = "edges"
-	StorageKeyIndexes                = "indexes"
+	StorageKeyTags       StorageKeyType = "tags"
+	StorageKeyEdges                     = "edges"
+	StorageKeyIndexes                   = "indexes"
+	StorageKeyVersions                  = "versions"
+	StorageKeyVertices                  = "vertices"
+	StorageKeyOperations                = "operations"
+	StorageKeySystem                    = "system"
+	StorageKeyKV                        = "kv"
+)
+
+// policies when the schema version of a row is missing
+const (
+	MissingSchemaFail    = "fail"
+	MissingSchemaSkip    = "skip"
+	MissingSchemaNearest = "nearest"
 )
 
 var MetaKeyTypeMap map[MetaKeyType]Parser
 var StorageKeyTypeMap map[StorageKeyType]Parser
+
+// ttl modes of tags and edges
+const (
+	TTLInclude = "include"
+	TTLExclude = "exclude"
+	TTLOnly    = "only"
+)
+
+// encodings of the raw kv
+const (
+	EncodingText   = "text"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
@@ -0,0 +1,230 @@
+package wal
+
+import (
+	"fmt"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// LogType is the type of the log written by storaged.
+type LogType byte
+
+const (
+	OpPut         LogType = 0x01
+	OpMultiPut    LogType = 0x02
+	OpRemove      LogType = 0x03
+	OpMultiRemove LogType = 0x04
+	OpRemoveRange LogType = 0x06
+	OpAddLearner  LogType = 0x07
+	OpTransLeader LogType = 0x08
+	OpAddPeer     LogType = 0x09
+	OpRemovePeer  LogType = 0x10
+	OpBatchWrite  LogType = 0x11
+)
+
+// operation types in the batch write log
+const (
+	opBatchPut         byte = 0x01
+	opBatchRemove      byte = 0x02
+	opBatchRemoveRange byte = 0x03
+)
+
+func (t LogType) String() string {
+	switch t {
+	case 0:
+		return "empty"
+	case OpPut:
+		return "put"
+	case OpMultiPut:
+		return "multi put"
+	case OpRemove:
+		return "remove"
+	case OpMultiRemove:
+		return "multi remove"
+	case OpRemoveRange:
+		return "remove range"
+	case OpAddLearner:
+		return "add learner"
+	case OpTransLeader:
+		return "transfer leader"
+	case OpAddPeer:
+		return "add peer"
+	case OpRemovePeer:
+		return "remove peer"
+	case OpBatchWrite:
+		return "batch write"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+}
+
+// Op is a modification in the log, Value is nil for remove,
+// Key and Value are the start and end for remove range.
+type Op struct {
+	Type  string
+	Key   []byte
+	Value []byte
+}
+
+// Log is the decoded log.
+// log: timestamp(8bit) + log type(1bit) + payload
+type Log struct {
+	Timestamp int64
+	Type      LogType
+	Ops       []*Op
+	// payload of the raft membership logs
+	Payload []byte
+}
+
+type logReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *logReader) next(l int) ([]byte, error) {
+	if l < 0 || r.pos+l > len(r.buf) {
+		return nil, fmt.Errorf("log is truncated, offset is %d, length is %d", r.pos, len(r.buf))
+	}
+	b := r.buf[r.pos : r.pos+l]
+	r.pos += l
+	return b, nil
+}
+
+func (r *logReader) uint32() (uint32, error) {
+	var v uint32
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	if err := common.ConvertBytesToInt(&v, &b, common.ByteOrder); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// str reads a length(4bit) + bytes
+func (r *logReader) str() ([]byte, error) {
+	l, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	return r.next(int(l))
+}
+
+// strs reads a count(4bit) + strings
+func (r *logReader) strs() ([][]byte, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, 0, n)
+	for i := uint32(0); i < n; i++ {
+		v, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// DecodeLog decodes the log of an entry, the empty log is the heartbeat of a new leader.
+func DecodeLog(buf []byte) (*Log, error) {
+	log := &Log{}
+	if len(buf) == 0 {
+		return log, nil
+	}
+	r := &logReader{buf: buf}
+	ts, err := r.next(8)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.ConvertBytesToInt(&log.Timestamp, &ts, common.ByteOrder); err != nil {
+		return nil, err
+	}
+	t, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	log.Type = LogType(t[0])
+
+	switch log.Type {
+	case OpPut:
+		k, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		log.Ops = append(log.Ops, &Op{Type: "put", Key: k, Value: v})
+	case OpRemove:
+		k, err := r.str()
+		if err != nil {
+			return nil, err
+		}
+		log.Ops = append(log.Ops, &Op{Type: "remove", Key: k})
+	case OpMultiPut:
+		kvs, err := r.strs()
+		if err != nil {
+			return nil, err
+		}
+		if len(kvs)%2 != 0 {
+			return nil, fmt.Errorf("invalid multi put log, count is %d", len(kvs))
+		}
+		for i := 0; i < len(kvs); i += 2 {
+			log.Ops = append(log.Ops, &Op{Type: "put", Key: kvs[i], Value: kvs[i+1]})
+		}
+	case OpMultiRemove:
+		keys, err := r.strs()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			log.Ops = append(log.Ops, &Op{Type: "remove", Key: k})
+		}
+	case OpRemoveRange:
+		keys, err := r.strs()
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) != 2 {
+			return nil, fmt.Errorf("invalid remove range log, count is %d", len(keys))
+		}
+		log.Ops = append(log.Ops, &Op{Type: "remove range", Key: keys[0], Value: keys[1]})
+	case OpBatchWrite:
+		// batch: count(4bit) + [operation type(1bit) + key + value]
+		n, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < n; i++ {
+			o, err := r.next(1)
+			if err != nil {
+				return nil, err
+			}
+			k, err := r.str()
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.str()
+			if err != nil {
+				return nil, err
+			}
+			switch o[0] {
+			case opBatchPut:
+				log.Ops = append(log.Ops, &Op{Type: "put", Key: k, Value: v})
+			case opBatchRemove:
+				log.Ops = append(log.Ops, &Op{Type: "remove", Key: k})
+			case opBatchRemoveRange:
+				log.Ops = append(log.Ops, &Op{Type: "remove range", Key: k, Value: v})
+			default:
+				return nil, fmt.Errorf("unknown batch operation %d", o[0])
+			}
+		}
+	default:
+		log.Payload = buf[r.pos:]
+	}
+	return log, nil
+}
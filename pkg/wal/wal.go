@@ -0,0 +1,153 @@
+package wal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+)
+
+// Entry is a log in the raft wal.
+// record: log id(8bit) + term(8bit) + log length(4bit) + cluster id(8bit) + log + log length(4bit)
+type Entry struct {
+	LogID     int64
+	Term      int64
+	ClusterID int64
+	Log       []byte
+}
+
+const headerLength = 8 + 8 + 4 + 8
+
+// ListFiles returns the wal files in the dir, sorted by the first log id.
+// the name of a wal file is the first log id in it, e.g. 0000000000000000001.wal
+func ListFiles(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".wal") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, info.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func firstLogID(file string) (int64, error) {
+	name := strings.TrimSuffix(filepath.Base(file), ".wal")
+	id, err := strconv.ParseInt(name, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wal file name %s", file)
+	}
+	return id, nil
+}
+
+// Read reads the entries from a wal file or all wal files in a dir,
+// only the entries in [from, to] are returned, to < 0 means no upper bound.
+func Read(path string, from, to int64, limit int) ([]*Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = ListFiles(path); err != nil {
+			return nil, err
+		}
+	}
+	entries := make([]*Entry, 0)
+	for i, f := range files {
+		if info.IsDir() {
+			// skip the files before from, or after to
+			if i+1 < len(files) {
+				next, err := firstLogID(files[i+1])
+				if err != nil {
+					return nil, err
+				}
+				if next <= from {
+					continue
+				}
+			}
+			first, err := firstLogID(f)
+			if err != nil {
+				return nil, err
+			}
+			if to >= 0 && first > to {
+				break
+			}
+		}
+		es, err := ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range es {
+			if e.LogID < from || (to >= 0 && e.LogID > to) {
+				continue
+			}
+			if len(entries) == limit {
+				return entries, nil
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// ReadFile reads all entries in a wal file.
+// the last entry may be truncated when storaged is killed, it is ignored with a warning.
+func ReadFile(file string) ([]*Entry, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0)
+	pos := 0
+	for pos < len(buf) {
+		if pos+headerLength > len(buf) {
+			common.Logger.Warnf("the last entry is truncated in %s, offset is %d", file, pos)
+			break
+		}
+		var (
+			e      = &Entry{}
+			length int32
+			footer int32
+		)
+		id, term, l, c := buf[pos:pos+8], buf[pos+8:pos+16], buf[pos+16:pos+20], buf[pos+20:pos+28]
+		if err := common.ConvertBytesToInt(&e.LogID, &id, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		if err := common.ConvertBytesToInt(&e.Term, &term, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		if err := common.ConvertBytesToInt(&length, &l, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		if err := common.ConvertBytesToInt(&e.ClusterID, &c, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		end := pos + headerLength + int(length) + 4
+		if length < 0 || end > len(buf) {
+			common.Logger.Warnf("the last entry is truncated in %s, log id is %d", file, e.LogID)
+			break
+		}
+		e.Log = buf[pos+headerLength : end-4]
+		f := buf[end-4 : end]
+		if err := common.ConvertBytesToInt(&footer, &f, common.ByteOrder); err != nil {
+			return nil, err
+		}
+		if footer != length {
+			return nil, fmt.Errorf("invalid entry in %s, log id is %d, length is %d, footer is %d", file, e.LogID, length, footer)
+		}
+		entries = append(entries, e)
+		pos = end
+	}
+	return entries, nil
+}
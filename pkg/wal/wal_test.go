@@ -0,0 +1,132 @@
+package wal
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harrischu/nebula-dump/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func appendStr(buf []byte, s string) []byte {
+	l := make([]byte, 4)
+	binary.LittleEndian.PutUint32(l, uint32(len(s)))
+	return append(append(buf, l...), s...)
+}
+
+func newLog(t LogType, strs ...string) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, 1672531200)
+	buf = append(buf, byte(t))
+	if t == OpMultiPut || t == OpMultiRemove || t == OpRemoveRange {
+		n := make([]byte, 4)
+		binary.LittleEndian.PutUint32(n, uint32(len(strs)))
+		buf = append(buf, n...)
+	}
+	for _, s := range strs {
+		buf = appendStr(buf, s)
+	}
+	return buf
+}
+
+func appendEntry(buf []byte, id, term int64, log []byte) []byte {
+	header := make([]byte, headerLength)
+	binary.LittleEndian.PutUint64(header, uint64(id))
+	binary.LittleEndian.PutUint64(header[8:], uint64(term))
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(log)))
+	binary.LittleEndian.PutUint64(header[20:], 7)
+	buf = append(append(buf, header...), log...)
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, uint32(len(log)))
+	return append(buf, footer...)
+}
+
+func TestRead(t *testing.T) {
+	common.SetUpLogs(ioutil.Discard, false)
+	dir, err := ioutil.TempDir("", "wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var first, second []byte
+	first = appendEntry(first, 1, 1, nil)
+	first = appendEntry(first, 2, 1, newLog(OpPut, "k1", "v1"))
+	second = appendEntry(second, 3, 2, newLog(OpMultiPut, "k2", "v2", "k3", "v3"))
+	second = appendEntry(second, 4, 2, newLog(OpRemove, "k1"))
+	// truncated
+	second = append(second, 5, 0, 0)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "0000000000000000001.wal"), first, 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "0000000000000000003.wal"), second, 0644))
+
+	entries, err := Read(dir, 0, -1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, int64(7), entries[0].ClusterID)
+
+	entries, err = Read(dir, 2, 3, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, int64(2), entries[0].LogID)
+	assert.Equal(t, int64(3), entries[1].LogID)
+	assert.Equal(t, int64(2), entries[1].Term)
+
+	entries, err = Read(filepath.Join(dir, "0000000000000000003.wal"), 0, -1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, int64(3), entries[0].LogID)
+}
+
+func TestDecodeLog(t *testing.T) {
+	log, err := DecodeLog(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "empty", log.Type.String())
+
+	log, err = DecodeLog(newLog(OpPut, "k1", "v1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1672531200), log.Timestamp)
+	assert.Equal(t, "put", log.Type.String())
+	assert.Equal(t, []*Op{{Type: "put", Key: []byte("k1"), Value: []byte("v1")}}, log.Ops)
+
+	log, err = DecodeLog(newLog(OpMultiPut, "k1", "v1", "k2", "v2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(log.Ops))
+	assert.Equal(t, []byte("k2"), log.Ops[1].Key)
+
+	log, err = DecodeLog(newLog(OpMultiRemove, "k1", "k2"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Op{Type: "remove", Key: []byte("k2")}, log.Ops[1])
+
+	log, err = DecodeLog(newLog(OpRemoveRange, "a", "z"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Op{Type: "remove range", Key: []byte("a"), Value: []byte("z")}, log.Ops[0])
+
+	batch := newLog(OpBatchWrite)
+	batch = append(batch, 2, 0, 0, 0)
+	batch = appendStr(appendStr(append(batch, opBatchPut), "k1"), "v1")
+	batch = appendStr(appendStr(append(batch, opBatchRemove), "k2"), "")
+	log, err = DecodeLog(batch)
+	assert.NoError(t, err)
+	assert.Equal(t, "batch write", log.Type.String())
+	assert.Equal(t, []*Op{
+		{Type: "put", Key: []byte("k1"), Value: []byte("v1")},
+		{Type: "remove", Key: []byte("k2")},
+	}, log.Ops)
+
+	log, err = DecodeLog(append(newLog(OpTransLeader), 1, 2, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer leader", log.Type.String())
+	assert.Equal(t, []byte{1, 2, 3}, log.Payload)
+
+	_, err = DecodeLog(newLog(OpPut, "k1")[:12])
+	assert.Error(t, err)
+}